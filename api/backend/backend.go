@@ -0,0 +1,28 @@
+// Package backend defines the operations shared by the open API client in
+// api/v1/client and the web API client in api/v2/client, so applications and
+// TickTickState can use either transport with the same domain types.
+package backend
+
+import (
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// Backend is an authenticated connection to a TickTick account.
+type Backend interface {
+	GetAllProjects(includeTasks bool) ([]*project.Project, error)
+	GetProjectById(id string, includeTasks bool) (*project.Project, error)
+	GetInbox() (*project.Project, error)
+	CreateNewProject(proj *project.Project) error
+	UpdateProject(proj *project.Project) error
+	DeleteProjectById(id string) error
+
+	// GetTask fills in task from the server, looking it up by its Id and,
+	// where the transport needs it, ProjectId.
+	GetTask(task *tasks.Task) error
+	CreateTask(task *tasks.Task) error
+	UpdateTask(task *tasks.Task) error
+	CompleteTask(task *tasks.Task) error
+	DeleteTask(task *tasks.Task) error
+	MoveTask(task *tasks.Task, toProjectID string) error
+}
@@ -0,0 +1,257 @@
+// Package tokenstore persists credentials such as OAuth tokens and web
+// sessions between runs, in the system keyring or in a file.
+package tokenstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	SERVICE  = "go-ticktick"
+	FILENAME = ".gott_auth2"
+	// LEGACY_KEY is the key a file written before keys existed, holding a
+	// single OAuth token, is read under.
+	LEGACY_KEY = "legacy-oauth-token"
+	// MAX_KEYRING_SIZE is the largest secret kept in the keyring, as Windows
+	// limits credential blobs to 2560 bytes.
+	MAX_KEYRING_SIZE = 2560
+)
+
+var (
+	ErrNotFound = errors.New("token not found")
+	ErrTooLarge = errors.New("secret too large for the keyring")
+)
+
+// Store keeps secrets by key. Load returns ErrNotFound for keys that were
+// never saved.
+type Store interface {
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+	Delete(key string) error
+}
+
+// Keyring stores secrets in the system keyring under Service.
+type Keyring struct {
+	Service string
+}
+
+func (k Keyring) Load(key string) ([]byte, error) {
+	secret, err := keyring.Get(k.Service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// Save fails with ErrTooLarge for secrets over MAX_KEYRING_SIZE, removing any
+// older entry for key so that it cannot shadow the secret saved elsewhere.
+func (k Keyring) Save(key string, data []byte) error {
+	if len(data) > MAX_KEYRING_SIZE {
+		if err := k.Delete(key); err != nil {
+			return err
+		}
+		return ErrTooLarge
+	}
+	return keyring.Set(k.Service, key, string(data))
+}
+
+func (k Keyring) Delete(key string) error {
+	err := keyring.Delete(k.Service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// File stores secrets as a JSON object of keys to values in a single file
+// readable only by the user. A file holding a bare OAuth token, as written by
+// earlier versions, is read as a single entry under LEGACY_KEY and rewritten
+// in the current format on the next change.
+type File struct {
+	Path string
+}
+
+type legacyToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+func (f File) read() (map[string]json.RawMessage, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]json.RawMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var legacy legacyToken
+	if json.Unmarshal(data, &legacy) == nil && legacy.AccessToken != "" {
+		v, err := json.Marshal(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]json.RawMessage{LEGACY_KEY: v}, nil
+	}
+	entries := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", f.Path, err)
+	}
+	return entries, nil
+}
+
+// write replaces the file atomically so a crash never loses other entries.
+func (f File) write(entries map[string]json.RawMessage) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.Path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+func (f File) Load(key string) ([]byte, error) {
+	entries, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := entries[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// Save refuses to write over a file it cannot read, which would lose the
+// other entries in it.
+func (f File) Save(key string, data []byte) error {
+	entries, err := f.read()
+	if err != nil {
+		return err
+	}
+	v, err := json.Marshal(string(data))
+	if err != nil {
+		return err
+	}
+	entries[key] = v
+	return f.write(entries)
+}
+
+func (f File) Delete(key string) error {
+	entries, err := f.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[key]; !ok {
+		return nil
+	}
+	delete(entries, key)
+	return f.write(entries)
+}
+
+// Chain tries its stores in order. Load returns the first entry found, Save
+// writes to the first store that accepts it and Delete removes the key from
+// all of them.
+type Chain []Store
+
+func (c Chain) Load(key string) ([]byte, error) {
+	var errs []error
+	for _, s := range c {
+		data, err := s.Load(key)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, ErrNotFound
+}
+
+func (c Chain) Save(key string, data []byte) error {
+	var errs []error
+	for _, s := range c {
+		err := s.Save(key, data)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (c Chain) Delete(key string) error {
+	var errs []error
+	for _, s := range c {
+		if err := s.Delete(key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DefaultFilePath returns the path of the file store in the user's home
+// directory.
+func DefaultFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, FILENAME), nil
+}
+
+// Default returns the system keyring, falling back to the file at
+// DefaultFilePath where no keyring is available.
+func Default() Store {
+	c := Chain{Keyring{Service: SERVICE}}
+	if path, err := DefaultFilePath(); err == nil {
+		c = append(c, File{Path: path})
+	}
+	return c
+}
+
+// LoadJSON decodes the entry for key into v.
+func LoadJSON(s Store, key string, v interface{}) error {
+	data, err := s.Load(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func SaveJSON(s Store, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.Save(key, data)
+}
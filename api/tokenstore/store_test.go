@@ -0,0 +1,154 @@
+package tokenstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// brokenStore fails every operation, like a keyring without a running
+// secret service.
+type brokenStore struct{}
+
+var errBroken = errors.New("no secret service")
+
+func (brokenStore) Load(key string) ([]byte, error) { return nil, errBroken }
+
+func (brokenStore) Save(key string, data []byte) error { return errBroken }
+
+func (brokenStore) Delete(key string) error { return errBroken }
+
+func TestStores(t *testing.T) {
+	keyring.MockInit()
+	dir := t.TempDir()
+	testCases := []struct {
+		name  string
+		store Store
+	}{
+		{"Keyring", Keyring{Service: "go-ticktick-test"}},
+		{"File", File{Path: filepath.Join(dir, "tokens.json")}},
+		{"Chain", Chain{Keyring{Service: "go-ticktick-test-chain"}, File{Path: filepath.Join(dir, "chain.json")}}},
+		{"Chain fallback", Chain{brokenStore{}, File{Path: filepath.Join(dir, "fallback.json")}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				if _, err := tc.store.Load("a"); !errors.Is(err, ErrNotFound) && tc.name != "Chain fallback" {
+					t.Fatalf("Expected ErrNotFound for a missing key, got %v", err)
+				}
+				if err := tc.store.Save("a", []byte(`{"token":"1"}`)); err != nil {
+					t.Fatal(err)
+				}
+				if err := tc.store.Save("b", []byte("2")); err != nil {
+					t.Fatal(err)
+				}
+				data, err := tc.store.Load("a")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != `{"token":"1"}` {
+					t.Errorf("Expected the saved value, got %s", data)
+				}
+				if err := tc.store.Delete("a"); err != nil && tc.name != "Chain fallback" {
+					t.Fatal(err)
+				}
+				if _, err := tc.store.Load("a"); err == nil {
+					t.Error("Expected a deleted key to be gone")
+				}
+				if data, err := tc.store.Load("b"); err != nil || string(data) != "2" {
+					t.Errorf("Expected other keys to be kept, got %s, %v", data, err)
+				}
+			},
+		)
+	}
+}
+
+func TestFilePermissions(t *testing.T) {
+	f := File{Path: filepath.Join(t.TempDir(), "nested", "tokens.json")}
+	var v struct{ Token string }
+	if err := SaveJSON(f, "k", map[string]string{"Token": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadJSON(f, "k", &v); err != nil || v.Token != "secret" {
+		t.Fatalf("Expected the token back, got %+v, %v", v, err)
+	}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the file to be private, got %s", info.Mode().Perm())
+	}
+}
+
+func TestFileLegacyToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.json")
+	legacy := `{"access_token":"abc","token_type":"bearer","expires_in":86400,"expires_time":1700000000}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := File{Path: path}
+	data, err := f.Load(LEGACY_KEY)
+	if err != nil || string(data) != legacy {
+		t.Fatalf("Expected the legacy token under %s, got %s, %v", LEGACY_KEY, data, err)
+	}
+	if _, err := f.Load("client"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for other keys, got %v", err)
+	}
+
+	if err := f.Save("client", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Delete(LEGACY_KEY); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := f.Load("client"); err != nil || string(data) != "new" {
+		t.Errorf("Expected the new entry, got %s, %v", data, err)
+	}
+	if _, err := f.Load(LEGACY_KEY); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the legacy token to be gone, got %v", err)
+	}
+}
+
+func TestFileCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	corrupt := `{"client": "abc",`
+	if err := os.WriteFile(path, []byte(corrupt), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f := File{Path: path}
+	if err := f.Save("session", []byte("new")); err == nil {
+		t.Fatal("Expected saving over an unreadable file to fail")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != corrupt {
+		t.Errorf("Expected the file to be left alone, got %s, %v", data, err)
+	}
+}
+
+func TestKeyringTooLarge(t *testing.T) {
+	keyring.MockInit()
+	k := Keyring{Service: "go-ticktick-test-large"}
+	if err := k.Save("a", []byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	large := make([]byte, MAX_KEYRING_SIZE+1)
+	for i := range large {
+		large[i] = 'x'
+	}
+	if err := k.Save("a", large); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+
+	c := Chain{k, File{Path: filepath.Join(t.TempDir(), "tokens.json")}}
+	if err := c.Save("a", large); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := c.Load("a"); err != nil || len(data) != len(large) {
+		t.Errorf("Expected the large secret from the file, got %d bytes, %v", len(data), err)
+	}
+}
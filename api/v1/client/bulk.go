@@ -0,0 +1,195 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+const DEFAULT_BULK_CONCURRENCY = 4
+
+type BulkResult struct {
+	Index int
+	Id    string
+	Err   error
+}
+
+type BulkReport struct {
+	Results []BulkResult
+}
+
+func newBulkReport(n int) *BulkReport {
+	r := &BulkReport{Results: make([]BulkResult, n)}
+	for i := range r.Results {
+		r.Results[i].Index = i
+	}
+	return r
+}
+
+func (r *BulkReport) Failed() []BulkResult {
+	var failed []BulkResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err joins the errors of every failed item, or returns nil if all succeeded.
+func (r *BulkReport) Err() error {
+	var errs []error
+	for _, res := range r.Failed() {
+		errs = append(errs, fmt.Errorf("item %d (%s): %w", res.Index, res.Id, res.Err))
+	}
+	return errors.Join(errs...)
+}
+
+type bulkOp int
+
+const (
+	bulkCreate bulkOp = iota
+	bulkUpdate
+	bulkComplete
+	bulkDelete
+)
+
+// CreateTasks creates every task in ts, using a single batch request per
+// v2.BATCH_SIZE tasks when a web client is attached and up to
+// BulkConcurrency parallel open API calls otherwise. Successfully created
+// tasks are updated in place; per-task failures are recorded in the report.
+func (c *TickTickClient) CreateTasks(ts []*tasks.Task) *BulkReport {
+	return c.runBulk(bulkCreate, ts)
+}
+
+func (c *TickTickClient) UpdateTasks(ts []*tasks.Task) *BulkReport {
+	return c.runBulk(bulkUpdate, ts)
+}
+
+func (c *TickTickClient) CompleteTasks(ts []*tasks.Task) *BulkReport {
+	return c.runBulk(bulkComplete, ts)
+}
+
+func (c *TickTickClient) DeleteTasks(ts []*tasks.Task) *BulkReport {
+	return c.runBulk(bulkDelete, ts)
+}
+
+func (c *TickTickClient) runBulk(op bulkOp, ts []*tasks.Task) *BulkReport {
+	report := newBulkReport(len(ts))
+	if c.webClient != nil {
+		c.runBulkBatch(op, ts, report)
+		return report
+	}
+	c.runBulkConcurrent(op, ts, report)
+	return report
+}
+
+func (c *TickTickClient) runBulkConcurrent(op bulkOp, ts []*tasks.Task, report *BulkReport) {
+	limit := c.BulkConcurrency
+	if limit <= 0 {
+		limit = DEFAULT_BULK_CONCURRENCY
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, t := range ts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, t *tasks.Task) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var err error
+			switch op {
+			case bulkCreate:
+				err = c.CreateTask(t)
+			case bulkUpdate:
+				err = c.UpdateTask(t)
+			case bulkComplete:
+				err = c.CompleteTask(t)
+			case bulkDelete:
+				err = c.DeleteTask(t)
+			}
+			report.Results[i].Id = t.Id
+			report.Results[i].Err = err
+		}(i, t)
+	}
+	wg.Wait()
+}
+
+func (c *TickTickClient) runBulkBatch(op bulkOp, ts []*tasks.Task, report *BulkReport) {
+	var err error
+	for start := 0; start < len(ts); start += v2.BATCH_SIZE {
+		end := min(start+v2.BATCH_SIZE, len(ts))
+		var (
+			indexes []int
+			payload []*tasks.Task
+			refs    []v2.TaskRef
+		)
+		for i := start; i < end; i++ {
+			t := ts[i]
+			report.Results[i].Id = t.Id
+			var err error
+			switch op {
+			case bulkCreate:
+				err = validateTask(t)
+			case bulkUpdate:
+				err = validateUpdateTask(t)
+			case bulkComplete, bulkDelete:
+				if t.Id == "" {
+					err = errors.New("task with an empty id cannot be modified")
+				}
+			}
+			if err == nil {
+				err = c.resolveTaskProject(t)
+			}
+			if err != nil {
+				report.Results[i].Err = err
+				continue
+			}
+			indexes = append(indexes, i)
+			switch op {
+			case bulkComplete:
+				completed := *t
+				completed.Status = tasks.Completed
+				completed.CompletedTime = time.Now().Local()
+				payload = append(payload, &completed)
+			case bulkDelete:
+				refs = append(refs, v2.TaskRef{TaskId: t.Id, ProjectId: t.ProjectId})
+			default:
+				payload = append(payload, t)
+			}
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		var resp *v2.BatchResponse
+		switch op {
+		case bulkCreate:
+			resp, err = c.webClient.BatchTasks(payload, nil, nil)
+		case bulkUpdate, bulkComplete:
+			resp, err = c.webClient.BatchTasks(nil, payload, nil)
+		case bulkDelete:
+			resp, err = c.webClient.BatchTasks(nil, nil, refs)
+		}
+		for n, i := range indexes {
+			t := ts[i]
+			report.Results[i].Id = t.Id
+			if err != nil {
+				report.Results[i].Err = err
+				continue
+			}
+			if msg, ok := resp.Id2Error[t.Id]; ok {
+				report.Results[i].Err = errors.New(msg)
+				continue
+			}
+			if op == bulkComplete {
+				t.Status = tasks.Completed
+				t.CompletedTime = payload[n].CompletedTime
+			}
+		}
+	}
+}
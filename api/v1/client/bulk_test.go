@@ -0,0 +1,158 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+func TestBulkReport(t *testing.T) {
+	r := newBulkReport(3)
+	r.Results[0].Id = "a"
+	r.Results[1].Id = "b"
+	r.Results[1].Err = errors.New("boom")
+	r.Results[2].Id = "c"
+
+	failed := r.Failed()
+	if len(failed) != 1 {
+		t.Fatalf("Expected 1 failed result, got %d", len(failed))
+	}
+	if failed[0].Index != 1 || failed[0].Id != "b" {
+		t.Fatalf("Expected failed item 1 with id b, got %d with id %s", failed[0].Index, failed[0].Id)
+	}
+	if r.Err() == nil {
+		t.Fatal("Expected a joined error")
+	}
+
+	if newBulkReport(2).Err() != nil {
+		t.Fatal("Expected no error from an all-successful report")
+	}
+}
+
+func TestBulkConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var paths []string
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			inFlight++
+			maxInFlight = max(maxInFlight, inFlight)
+			paths = append(paths, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/bad"):
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"errorCode": "unknown"})
+			default:
+				var task tasks.Task
+				json.NewDecoder(r.Body).Decode(&task)
+				writeJSON(w, http.StatusOK, &task)
+			}
+		},
+	)
+	c := newTestClient(t, mux, false)
+	c.BulkConcurrency = 2
+	c.rememberInboxId("inbox1")
+
+	ts := []*tasks.Task{
+		{Id: "t1", ProjectId: "p1", Title: "a"},
+		{Id: "bad", ProjectId: "p1", Title: "b"},
+		{Id: "t3", Title: "c"},
+		{Id: "t4", ProjectId: "p1"},
+		{Id: "t5", ProjectId: "p1", Title: "e"},
+	}
+	report := c.UpdateTasks(ts)
+	failed := report.Failed()
+	if len(failed) != 2 || failed[0].Id != "bad" || failed[1].Index != 3 {
+		t.Fatalf("Expected the rejected update and the untitled task to fail, got %+v", failed)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", maxInFlight)
+	}
+	if ts[2].ProjectId != "inbox1" {
+		t.Errorf("Expected the inbox to be resolved, got %q", ts[2].ProjectId)
+	}
+
+	paths = nil
+	report = c.DeleteTasks([]*tasks.Task{{Id: "t1"}, {Id: "bad", ProjectId: "p1"}})
+	if len(report.Failed()) != 1 || report.Failed()[0].Index != 1 {
+		t.Errorf("Expected only the second delete to fail, got %+v", report.Failed())
+	}
+	sort.Strings(paths)
+	if len(paths) != 2 || paths[0] != "DELETE /open/v1/project/inbox1/task/t1" {
+		t.Errorf("Expected deletes against resolved projects, got %v", paths)
+	}
+}
+
+func TestBulkBatch(t *testing.T) {
+	var batches []map[string][]json.RawMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1", "inboxId": "inbox1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/task", func(w http.ResponseWriter, r *http.Request) {
+			var batch map[string][]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&batch)
+			batches = append(batches, batch)
+			writeJSON(
+				w, http.StatusOK, map[string]interface{}{
+					"id2etag":  map[string]string{"t1": "e1"},
+					"id2error": map[string]string{"t2": "TASK_NOT_FOUND"},
+				},
+			)
+		},
+	)
+	c := newTestClient(t, mux, true)
+	c.rememberInboxId("inbox1")
+
+	ts := []*tasks.Task{{Id: "t1"}, {Id: "t2", ProjectId: "p1"}, {ProjectId: "p1"}}
+	report := c.DeleteTasks(ts)
+	failed := report.Failed()
+	if len(failed) != 2 || failed[0].Id != "t2" || failed[1].Index != 2 {
+		t.Fatalf("Expected t2 and the task without an id to fail, got %+v", failed)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("Expected one batch request, got %d", len(batches))
+	}
+	var refs []v2.TaskRef
+	for _, raw := range batches[0]["delete"] {
+		var ref v2.TaskRef
+		json.Unmarshal(raw, &ref)
+		refs = append(refs, ref)
+	}
+	want := []v2.TaskRef{{TaskId: "t1", ProjectId: "inbox1"}, {TaskId: "t2", ProjectId: "p1"}}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("Expected refs %+v, got %+v", want, refs)
+	}
+
+	batches = nil
+	many := make([]*tasks.Task, v2.BATCH_SIZE+1)
+	for i := range many {
+		many[i] = &tasks.Task{Id: "t1", ProjectId: "p1", Title: "task"}
+	}
+	report = c.CompleteTasks(many)
+	if len(batches) != 2 || len(batches[0]["update"]) != v2.BATCH_SIZE || len(batches[1]["update"]) != 1 {
+		t.Fatalf("Expected the tasks split into batches of %d, got %d batches", v2.BATCH_SIZE, len(batches))
+	}
+	if report.Err() != nil || many[0].Status != tasks.Completed {
+		t.Errorf("Expected every task completed, got %v", report.Err())
+	}
+}
@@ -0,0 +1,230 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func checklistItemIndex(task *tasks.Task, itemID string) (int, error) {
+	for i, cl := range task.ChecklistItems {
+		if cl.Id == itemID {
+			return i, nil
+		}
+	}
+	return -1, errors.New(fmt.Sprintf("checklist item with id %s not found on task %s", itemID, task.Id))
+}
+
+// updateChecklist applies mutate to a copy of task, validates the resulting
+// checklist items and sends the update. task is only modified if the update
+// succeeds.
+func (c *TickTickClient) updateChecklist(task *tasks.Task, mutate func(t *tasks.Task) error) error {
+	updated := task.Clone()
+	if err := mutate(&updated); err != nil {
+		return err
+	}
+	for i := range updated.ChecklistItems {
+		if err := validateChecklistItem(&updated.ChecklistItems[i]); err != nil {
+			return err
+		}
+	}
+	if err := c.UpdateTask(&updated); err != nil {
+		return err
+	}
+	*task = updated
+	return nil
+}
+
+func (c *TickTickClient) AddChecklistItem(task *tasks.Task, item tasks.ChecklistItem) error {
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			if item.SortOrder == 0 && len(t.ChecklistItems) > 0 {
+				item.SortOrder = t.ChecklistItems[len(t.ChecklistItems)-1].SortOrder + 1
+			}
+			t.ChecklistItems = append(t.ChecklistItems, item)
+			t.Kind = tasks.Checklist
+			return nil
+		},
+	)
+}
+
+func (c *TickTickClient) EditChecklistItem(task *tasks.Task, item tasks.ChecklistItem) error {
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			i, err := checklistItemIndex(t, item.Id)
+			if err != nil {
+				return err
+			}
+			t.ChecklistItems[i] = item
+			return nil
+		},
+	)
+}
+
+// ReorderChecklistItems orders the task's checklist items to match itemIDs,
+// which must contain the id of every item exactly once.
+func (c *TickTickClient) ReorderChecklistItems(task *tasks.Task, itemIDs []string) error {
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			if len(itemIDs) != len(t.ChecklistItems) {
+				return errors.New(
+					fmt.Sprintf("expected %d checklist item ids, got %d", len(t.ChecklistItems), len(itemIDs)),
+				)
+			}
+			reordered := make([]tasks.ChecklistItem, 0, len(itemIDs))
+			seen := map[string]bool{}
+			for i, id := range itemIDs {
+				if seen[id] {
+					return errors.New(fmt.Sprintf("checklist item id %s given more than once", id))
+				}
+				seen[id] = true
+				j, err := checklistItemIndex(t, id)
+				if err != nil {
+					return err
+				}
+				cl := t.ChecklistItems[j]
+				cl.SortOrder = i
+				reordered = append(reordered, cl)
+			}
+			t.ChecklistItems = reordered
+			return nil
+		},
+	)
+}
+
+func (c *TickTickClient) CheckChecklistItem(task *tasks.Task, itemID string) error {
+	return c.setChecklistItemStatus(task, itemID, tasks.Completed)
+}
+
+func (c *TickTickClient) UncheckChecklistItem(task *tasks.Task, itemID string) error {
+	return c.setChecklistItemStatus(task, itemID, tasks.Normal)
+}
+
+func (c *TickTickClient) setChecklistItemStatus(task *tasks.Task, itemID string, status tasks.Status) error {
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			i, err := checklistItemIndex(t, itemID)
+			if err != nil {
+				return err
+			}
+			t.ChecklistItems[i].Status = status
+			if status == tasks.Completed {
+				t.ChecklistItems[i].CompletedTime = time.Now().Local()
+			} else {
+				t.ChecklistItems[i].CompletedTime = time.Time{}
+			}
+			return nil
+		},
+	)
+}
+
+func (c *TickTickClient) DeleteChecklistItem(task *tasks.Task, itemID string) error {
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			i, err := checklistItemIndex(t, itemID)
+			if err != nil {
+				return err
+			}
+			t.ChecklistItems = append(t.ChecklistItems[:i], t.ChecklistItems[i+1:]...)
+			return nil
+		},
+	)
+}
+
+// ChecklistItemToTask creates a standalone task in the same project from the
+// checklist item and removes the item from task.
+func (c *TickTickClient) ChecklistItemToTask(task *tasks.Task, itemID string) (*tasks.Task, error) {
+	i, err := checklistItemIndex(task, itemID)
+	if err != nil {
+		return nil, err
+	}
+	cl := task.ChecklistItems[i]
+	if err := validateChecklistItem(&cl); err != nil {
+		return nil, err
+	}
+	nt := &tasks.Task{
+		ProjectId:     task.ProjectId,
+		Title:         cl.Title,
+		Status:        cl.Status,
+		CompletedTime: cl.CompletedTime,
+		IsAllDay:      cl.IsAllDay,
+		StartDate:     cl.StartDate,
+		TimeZone:      cl.TimeZone,
+	}
+	if err := c.CreateTask(nt); err != nil {
+		return nil, err
+	}
+	if err := c.DeleteChecklistItem(task, itemID); err != nil {
+		return nt, err
+	}
+	return nt, nil
+}
+
+// TaskToChecklistItem adds child as a checklist item on parent and deletes
+// child.
+func (c *TickTickClient) TaskToChecklistItem(parent *tasks.Task, child *tasks.Task) error {
+	item := tasks.ChecklistItem{
+		Title:         child.Title,
+		Status:        child.Status,
+		CompletedTime: child.CompletedTime,
+		IsAllDay:      child.IsAllDay,
+		StartDate:     child.StartDate,
+		TimeZone:      child.TimeZone,
+	}
+	if err := c.AddChecklistItem(parent, item); err != nil {
+		return err
+	}
+	return c.DeleteTask(child)
+}
+
+// ConvertTaskKind switches a task between TEXT, CHECKLIST and NOTE. Converting
+// to a checklist turns each non-empty line of the content into an item;
+// converting to text or a note writes the item titles into the content, one
+// per line.
+func (c *TickTickClient) ConvertTaskKind(task *tasks.Task, kind tasks.Kind) error {
+	if kind.String() == "" {
+		return errors.New(fmt.Sprintf("%d is not a valid task Kind", kind))
+	}
+	if task.Kind == kind {
+		return nil
+	}
+	return c.updateChecklist(
+		task, func(t *tasks.Task) error {
+			switch kind {
+			case tasks.Checklist:
+				for _, line := range strings.Split(t.Content, "\n") {
+					line = strings.TrimSpace(line)
+					if line == "" {
+						continue
+					}
+					t.ChecklistItems = append(
+						t.ChecklistItems, tasks.ChecklistItem{
+							Title:     line,
+							SortOrder: len(t.ChecklistItems),
+						},
+					)
+				}
+				t.Content = ""
+			case tasks.Text, tasks.Note:
+				var lines []string
+				if t.Content != "" {
+					lines = append(lines, t.Content)
+				}
+				for _, cl := range t.ChecklistItems {
+					lines = append(lines, cl.Title)
+				}
+				t.Content = strings.Join(lines, "\n")
+				t.ChecklistItems = nil
+				if kind == tasks.Note {
+					t.StartDate = time.Time{}
+					t.DueDate = time.Time{}
+				}
+			}
+			t.Kind = kind
+			return nil
+		},
+	)
+}
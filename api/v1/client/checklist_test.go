@@ -0,0 +1,200 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+type sentItem struct {
+	Id            string `json:"id"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	CompletedTime string `json:"completedTime"`
+	SortOrder     int    `json:"sortOrder"`
+}
+
+type sentTask struct {
+	Id    string     `json:"id"`
+	Kind  string     `json:"kind"`
+	Items []sentItem `json:"items"`
+}
+
+func newChecklistTask() *tasks.Task {
+	return &tasks.Task{
+		Id:        "t1",
+		ProjectId: "p1",
+		Title:     "Pack",
+		Kind:      tasks.Checklist,
+		ChecklistItems: []tasks.ChecklistItem{
+			{Id: "c1", Title: "Socks", SortOrder: 0},
+			{Id: "c2", Title: "Charger", SortOrder: 1, Status: tasks.Completed},
+		},
+	}
+}
+
+func TestClientChecklistItems(t *testing.T) {
+	var sent []sentTask
+	status := http.StatusOK
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/open/v1/task/", func(w http.ResponseWriter, r *http.Request) {
+			var raw json.RawMessage
+			json.NewDecoder(r.Body).Decode(&raw)
+			var st sentTask
+			json.Unmarshal(raw, &st)
+			sent = append(sent, st)
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(raw)
+		},
+	)
+	c := newTestClient(t, mux, false)
+
+	testCases := []struct {
+		name   string
+		run    func(task *tasks.Task) error
+		status int
+		// want is the items sent without their completion time, nil if no
+		// request should be made.
+		want    []sentItem
+		wantErr bool
+	}{
+		{
+			name: "Add",
+			run: func(task *tasks.Task) error {
+				return c.AddChecklistItem(task, tasks.ChecklistItem{Title: "Passport"})
+			},
+			want: []sentItem{{Id: "c1", Title: "Socks"}, {Id: "c2", Title: "Charger", Status: 1, SortOrder: 1}, {Title: "Passport", SortOrder: 2}},
+		},
+		{
+			name: "Edit",
+			run: func(task *tasks.Task) error {
+				return c.EditChecklistItem(task, tasks.ChecklistItem{Id: "c1", Title: "Wool socks"})
+			},
+			want: []sentItem{{Id: "c1", Title: "Wool socks"}, {Id: "c2", Title: "Charger", Status: 1, SortOrder: 1}},
+		},
+		{
+			name: "Check",
+			run: func(task *tasks.Task) error {
+				return c.CheckChecklistItem(task, "c1")
+			},
+			want: []sentItem{{Id: "c1", Title: "Socks", Status: 1}, {Id: "c2", Title: "Charger", Status: 1, SortOrder: 1}},
+		},
+		{
+			name: "Uncheck",
+			run: func(task *tasks.Task) error {
+				return c.UncheckChecklistItem(task, "c2")
+			},
+			want: []sentItem{{Id: "c1", Title: "Socks"}, {Id: "c2", Title: "Charger", SortOrder: 1}},
+		},
+		{
+			name: "Reorder",
+			run: func(task *tasks.Task) error {
+				return c.ReorderChecklistItems(task, []string{"c2", "c1"})
+			},
+			want: []sentItem{{Id: "c2", Title: "Charger", Status: 1}, {Id: "c1", Title: "Socks", SortOrder: 1}},
+		},
+		{
+			name: "Delete",
+			run: func(task *tasks.Task) error {
+				return c.DeleteChecklistItem(task, "c1")
+			},
+			want: []sentItem{{Id: "c2", Title: "Charger", Status: 1, SortOrder: 1}},
+		},
+		{
+			name: "Unknown item",
+			run: func(task *tasks.Task) error {
+				return c.CheckChecklistItem(task, "c9")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty title",
+			run: func(task *tasks.Task) error {
+				return c.EditChecklistItem(task, tasks.ChecklistItem{Id: "c1"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate id in reorder",
+			run: func(task *tasks.Task) error {
+				return c.ReorderChecklistItems(task, []string{"c1", "c1"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "Server error",
+			run: func(task *tasks.Task) error {
+				return c.DeleteChecklistItem(task, "c1")
+			},
+			status:  http.StatusInternalServerError,
+			want:    []sentItem{{Id: "c2", Title: "Charger", Status: 1, SortOrder: 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				sent = nil
+				status = http.StatusOK
+				if tc.status != 0 {
+					status = tc.status
+				}
+				task := newChecklistTask()
+				err := tc.run(task)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("Expected error to be %t, got %v", tc.wantErr, err)
+				}
+				if tc.want == nil {
+					if len(sent) != 0 {
+						t.Fatalf("Expected no request, got %+v", sent)
+					}
+				} else {
+					if len(sent) != 1 || sent[0].Id != "t1" || sent[0].Kind != "CHECKLIST" {
+						t.Fatalf("Expected one update of checklist t1, got %+v", sent)
+					}
+					var got []sentItem
+					for _, item := range sent[0].Items {
+						item.CompletedTime = ""
+						got = append(got, item)
+					}
+					if !reflect.DeepEqual(got, tc.want) {
+						t.Errorf("Expected items %+v, got %+v", tc.want, got)
+					}
+				}
+				if tc.wantErr {
+					if !reflect.DeepEqual(task, newChecklistTask()) {
+						t.Errorf("Expected the task to be unchanged after an error, got %+v", task)
+					}
+					return
+				}
+				if len(task.ChecklistItems) != len(tc.want) {
+					t.Errorf("Expected the task to hold the sent items, got %+v", task.ChecklistItems)
+				}
+			},
+		)
+	}
+
+	sent, status = nil, http.StatusOK
+	task := newChecklistTask()
+	if err := c.CheckChecklistItem(task, "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if sent[0].Items[0].CompletedTime == "" || task.ChecklistItems[0].Status != tasks.Completed {
+		t.Errorf("Expected a checked item to be sent with a completion time, got %+v", sent[0].Items[0])
+	}
+	if err := c.UncheckChecklistItem(task, "c1"); err != nil {
+		t.Fatal(err)
+	}
+	if sent[1].Items[0].CompletedTime != "" || !task.ChecklistItems[0].CompletedTime.IsZero() {
+		t.Errorf("Expected an unchecked item to be sent without a completion time, got %+v", sent[1].Items[0])
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
+	"github.com/herzs11/go-ticktick/api/backend"
+	"github.com/herzs11/go-ticktick/api/tokenstore"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
 	"github.com/pkg/browser"
 )
 
@@ -17,8 +21,10 @@ const (
 	OAUTH_BASE_URL              = "https://ticktick.com/oauth"
 	AUTHORIZATION_PAGE_ENDPOINT = "/authorize"
 	ACCESS_TOKEN_ENDPOINT       = "/token"
-	KEYRING_SERVICE             = "go-ticktick"
+	KEYRING_SERVICE             = tokenstore.SERVICE
 	API_BASE_URL                = "https://api.ticktick.com"
+	// DEFAULT_TIMEOUT bounds each request made with the default HTTP client.
+	DEFAULT_TIMEOUT = 30 * time.Second
 )
 
 type oauthToken struct {
@@ -63,10 +69,18 @@ type TickTickClient struct {
 	ClientId          string
 	ClientSecret      string
 	RedirectURI       string
+	BulkConcurrency   int
 	authorizationCode string
 	token             oauthToken
+	webClient         *v2.Client
+	tokenStore        tokenstore.Store
+	httpClient        *http.Client
+	inboxId           string
+	inboxMu           sync.Mutex
 }
 
+var _ backend.Backend = (*TickTickClient)(nil)
+
 func createAuthorizationCodeListener(authCh chan string, serv *http.Server, path string) {
 	http.HandleFunc(
 		path, func(w http.ResponseWriter, r *http.Request) {
@@ -101,6 +115,24 @@ func NewTickTickClient(clientID, clientSecret, redirectURI string) *TickTickClie
 	}
 }
 
+// SetWebClient attaches a v2 web API client, which is used for operations the
+// open API does not support, such as moving tasks between projects.
+func (oc *TickTickClient) SetWebClient(wc *v2.Client) {
+	oc.webClient = wc
+}
+
+// SetHTTPClient sets the HTTP client requests are sent with, by default one
+// with a DEFAULT_TIMEOUT timeout.
+func (oc *TickTickClient) SetHTTPClient(hc *http.Client) {
+	oc.httpClient = hc
+}
+
+// SetTokenStore sets where Authenticate keeps the OAuth token between runs.
+// By default it uses tokenstore.Default().
+func (oc *TickTickClient) SetTokenStore(store tokenstore.Store) {
+	oc.tokenStore = store
+}
+
 func (oc *TickTickClient) getAuthURL() string {
 	params := map[string]string{
 		"client_id":     oc.ClientId,
@@ -205,18 +237,13 @@ func (oc *TickTickClient) getOauthToken() error {
 }
 
 func (oc *TickTickClient) Authenticate() error {
-	token, err := getTokenFromKeyring(oc.ClientId)
-	if err != nil {
-		log.Printf("Could not get token from keyring: %s\n", err.Error())
+	store := oc.tokenStore
+	if store == nil {
+		store = tokenstore.Default()
 	}
-	if token != nil {
-		oc.token = *token
-		return nil
-	}
-
-	token, err = getTokenFromFile()
+	token, err := loadToken(store, oc.ClientId)
 	if err != nil {
-		log.Printf("Could not get token from file")
+		log.Printf("Could not get token from token store: %s\n", err.Error())
 	}
 	if token != nil {
 		oc.token = *token
@@ -235,8 +262,7 @@ func (oc *TickTickClient) Authenticate() error {
 	if !oc.token.validate() {
 		return errors.New("Cannot validate token")
 	}
-	err = storeToken(oc.ClientId, oc.token)
-	return err
+	return storeToken(store, oc.ClientId, oc.token)
 }
 
 func (c *TickTickClient) Do(req *http.Request) (*http.Response, error) {
@@ -244,6 +270,9 @@ func (c *TickTickClient) Do(req *http.Request) (*http.Response, error) {
 	if req.Method == "POST" {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	httpClient := http.Client{}
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DEFAULT_TIMEOUT}
+	}
 	return httpClient.Do(req)
 }
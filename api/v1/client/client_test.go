@@ -68,12 +68,12 @@ func TestTokenFileStore(t *testing.T) {
 	c := getAuthenticatedClient(t)
 	expTS := time.Second * time.Duration(c.token.ExpiresIn)
 	c.token.ExpiresTime = time.Now().Add(expTS).Unix()
-	err := storeTokenFile(c.token)
+	err := storeTokenFile(c.ClientId, c.token)
 	if err != nil {
 		log.Fatal(err)
 	}
 	
-	retrievedToken, err := getTokenFromFile()
+	retrievedToken, err := getTokenFromFile(c.ClientId)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -287,8 +287,12 @@ func TestGetInbox(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if inbox.Id != "inbox" {
-		t.Fatalf("Expected inbox, got %s", inbox.Id)
+	inboxID, err := c.InboxId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inbox.Id != inboxID || inbox.Id == "inbox" {
+		t.Fatalf("Expected %s, got %s", inboxID, inbox.Id)
 	}
 	if inbox.Name != "Inbox" {
 		t.Fatalf("Expected Inbox, got %s", inbox.Name)
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func (c *TickTickClient) CreateNoteProject(name string) (*project.Project, error) {
+	p := project.Project{Name: name, Kind: project.Note}
+	err := c.CreateNewProject(&p)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateNote creates a note with a Markdown body in the note project with id
+// projectID.
+func (c *TickTickClient) CreateNote(projectID, title, markdown string) (*tasks.Task, error) {
+	p, err := c.GetProjectById(projectID, false)
+	if err != nil {
+		return nil, err
+	}
+	if p.Kind != project.Note {
+		return nil, errors.New(fmt.Sprintf("project %s is not a note project", projectID))
+	}
+	note := &tasks.Task{
+		ProjectId: projectID,
+		Title:     title,
+		Kind:      tasks.Note,
+	}
+	note.SetMarkdown(markdown)
+	if err := c.CreateTask(note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (c *TickTickClient) GetNotes(projectID string) ([]tasks.Task, error) {
+	p, err := c.GetProjectById(projectID, true)
+	if err != nil {
+		return nil, err
+	}
+	var notes []tasks.Task
+	for _, t := range p.Tasks {
+		if t.IsNote() {
+			notes = append(notes, t)
+		}
+	}
+	return notes, nil
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
 )
 
 func (c *TickTickClient) CreateNewProjectFromName(name string) (*project.Project, error) {
@@ -29,7 +30,7 @@ func (c *TickTickClient) CreateNewProject(proj *project.Project) error {
 		Name:     proj.Name,
 		Color:    proj.Color,
 		ViewMode: proj.ViewMode.String(),
-		Kind:     proj.ViewMode.String(),
+		Kind:     proj.Kind.String(),
 	}
 
 	data, err := json.Marshal(projParams)
@@ -83,11 +84,95 @@ func (c *TickTickClient) GetInbox() (*project.Project, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, t := range p.Tasks {
+		if strings.HasPrefix(t.ProjectId, "inbox") {
+			c.rememberInboxId(t.ProjectId)
+			break
+		}
+	}
 	p.Id = "inbox"
+	c.inboxMu.Lock()
+	cached := c.inboxId
+	c.inboxMu.Unlock()
+	if cached != "" {
+		p.Id = cached
+	} else if c.webClient != nil {
+		if id, err := c.InboxId(); err == nil {
+			p.Id = id
+		}
+	}
 	p.Name = "Inbox"
 	return p, nil
 }
 
+// ErrInboxUnresolved is returned by InboxId when the inbox has no tasks to
+// learn its id from and no web client is attached.
+var ErrInboxUnresolved = errors.New("unable to resolve inbox project id")
+
+// InboxId returns the concrete id of the user's inbox project (e.g.
+// "inbox123456"). It is discovered once, from the web client's login response
+// when one is attached or from the inbox tasks otherwise, and then cached.
+func (c *TickTickClient) InboxId() (string, error) {
+	c.inboxMu.Lock()
+	id := c.inboxId
+	c.inboxMu.Unlock()
+	if id != "" {
+		return id, nil
+	}
+	if c.webClient != nil {
+		id, err := c.webClient.GetInboxId()
+		if err == nil && id != "" {
+			c.rememberInboxId(id)
+			return id, nil
+		}
+	}
+	p, err := c.GetProjectById("inbox", true)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range p.Tasks {
+		if strings.HasPrefix(t.ProjectId, "inbox") {
+			c.rememberInboxId(t.ProjectId)
+			return t.ProjectId, nil
+		}
+	}
+	return "", ErrInboxUnresolved
+}
+
+func (c *TickTickClient) rememberInboxId(id string) {
+	c.inboxMu.Lock()
+	defer c.inboxMu.Unlock()
+	if c.inboxId == "" {
+		c.inboxId = id
+	}
+}
+
+func (c *TickTickClient) resolveProjectId(id string) (string, error) {
+	if id == "" || id == "inbox" {
+		return c.InboxId()
+	}
+	return id, nil
+}
+
+// resolveTaskProject replaces an empty or literal inbox ProjectId with the
+// inbox's id. While that is unknown the literal inbox is kept, which the open
+// API accepts, and CreateTask learns the id from the response.
+func (c *TickTickClient) resolveTaskProject(t *tasks.Task) error {
+	if t.ProjectId != "" && t.ProjectId != "inbox" {
+		return nil
+	}
+	id, err := c.InboxId()
+	if errors.Is(err, ErrInboxUnresolved) {
+		t.ProjectId = "inbox"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t.ProjectId = id
+	return nil
+}
+
 func (c *TickTickClient) GetProjectById(id string, includeTasks bool) (*project.Project, error) {
 	if strings.HasPrefix(id, "inbox") && !includeTasks {
 		return nil, errors.New("must return tasks with inbox")
@@ -143,7 +228,7 @@ func (c *TickTickClient) UpdateProject(proj *project.Project) error {
 		Name:     proj.Name,
 		Color:    proj.Color,
 		ViewMode: proj.ViewMode.String(),
-		Kind:     proj.ViewMode.String(),
+		Kind:     proj.Kind.String(),
 	}
 
 	data, err := json.Marshal(projParams)
@@ -1,21 +1,120 @@
 package client
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"log"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/herzs11/go-ticktick/api/backend"
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
 	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
 )
 
+// StateClient is the client behaviour TickTickState needs.
+//
+// Deprecated: use backend.Backend, which both clients implement.
+type StateClient = backend.Backend
+
 type TickTickState struct {
-	*TickTickClient
+	client     backend.Backend
+	lazy       bool
+	loaded     bool
+	index      *stateIndex
+	checkPoint int64
+	store      StateStore
+	// Deprecated: Projects is read and replaced by syncs under an internal
+	// lock, so reading it while the state syncs is a data race. Use
+	// GetProjects, which returns copies.
 	Projects []*project.Project
-	*sync.Mutex
+	filters  []*filter.Filter
+	tags     []*tag.Tag
+	// generation counts changes to the model, so a sync can tell whether it
+	// changed while the sync was downloading.
+	generation uint64
+	mu         sync.Mutex
+	// syncMu serializes syncs. It is taken before mu.
+	syncMu sync.Mutex
+
+	syncInterval time.Duration
+	syncJitter   time.Duration
+	maxBackoff   time.Duration
+	runMu        sync.Mutex
+	status       SyncStatus
+
+	offline  bool
+	queue    []QueuedOp
+	localIds map[string]string
+	resolver ConflictResolver
+	bases    map[string]tasks.Task
+
+	subMu         sync.Mutex
+	subscribers   []*Subscription
+	pendingEvents []Event
+}
+
+type StateOption func(*TickTickState)
+
+// WithLazyLoad defers the initial fetch until the state is first read,
+// instead of loading everything in the constructor.
+func WithLazyLoad() StateOption {
+	return func(ts *TickTickState) {
+		ts.lazy = true
+	}
 }
 
-func NewTickTickState() (*TickTickState, error) {
+func WithEagerLoad() StateOption {
+	return func(ts *TickTickState) {
+		ts.lazy = false
+	}
+}
+
+// NewTickTickState creates a state backed by an already authenticated client.
+func NewTickTickState(c backend.Backend, opts ...StateOption) (*TickTickState, error) {
+	if c == nil {
+		return nil, errors.New("must provide a client for the state")
+	}
+	ts := &TickTickState{
+		client:       c,
+		index:        newStateIndex(),
+		syncInterval: DEFAULT_SYNC_INTERVAL,
+		syncJitter:   -1,
+		maxBackoff:   DEFAULT_MAX_BACKOFF,
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	if ts.syncJitter < 0 {
+		ts.syncJitter = ts.syncInterval / 10
+	}
+	cached, err := ts.loadStore()
+	if err != nil {
+		log.Printf("Could not load state from store: %s\n", err)
+	}
+	if ts.lazy {
+		return ts, nil
+	}
+	if cached {
+		if err := ts.Sync(context.Background()); err != nil {
+			log.Printf("Could not sync state, continuing from cache: %s\n", err)
+		}
+		return ts, nil
+	}
+	err = ts.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// NewTickTickStateFromEnv authenticates a client using the TT_CLIENT_ID,
+// TT_CLIENT_SECRET and TT_REDIRECT_URI environment variables and creates a
+// state from it.
+func NewTickTickStateFromEnv(opts ...StateOption) (*TickTickState, error) {
 	clientID := os.Getenv("TT_CLIENT_ID")
 	if clientID == "" {
 		return nil, errors.New("TT_CLIENT_ID environment variable is not set")
@@ -33,43 +132,102 @@ func NewTickTickState() (*TickTickState, error) {
 	if err != nil {
 		return nil, err
 	}
-	ts := &TickTickState{
-		TickTickClient: c,
-		Mutex:          &sync.Mutex{},
-	}
-	err = ts.GetAll()
-	if err != nil {
-		return nil, err
-	}
-	return ts, nil
+	return NewTickTickState(c, opts...)
+}
+
+func (ts *TickTickState) Client() backend.Backend {
+	return ts.client
 }
 
+// GetAll reloads the whole model. The download does not hold the model lock,
+// so reads and mutations go on while it is in flight.
 func (ts *TickTickState) GetAll() error {
-	ts.Lock()
-	defer ts.Unlock()
-	projs, err := ts.TickTickClient.GetAllProjects(true)
-	if err != nil {
-		return err
-	}
-	inbox, err := ts.GetInbox()
+	defer ts.flushEvents()
+	ts.syncMu.Lock()
+	defer ts.syncMu.Unlock()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.getAll()
+}
+
+// getAll reloads the whole model. The caller must hold syncMu and the lock,
+// which is released while downloading.
+func (ts *TickTickState) getAll() error {
+	apply, err := ts.pullLatest(context.Background(), true)
 	if err != nil {
 		return err
 	}
-	projs = append(projs, inbox)
-	ts.Projects = projs
+	apply()
+	ts.reapplyQueue()
 	return nil
 }
 
-func (ts *TickTickState) WriteToJSON() error {
-	data, err := json.Marshal(ts.Projects)
-	if err != nil {
-		return err
+// replaceModel swaps in a freshly loaded set of projects, queueing events for
+// what changed if the state was already loaded. Tasks missing from projs are
+// reported as completed if their ids are in completed and as deleted
+// otherwise. The caller must hold the lock.
+func (ts *TickTickState) replaceModel(projs []*project.Project, completed map[string]bool) {
+	old, wasLoaded := ts.index, ts.loaded
+	ts.generation++
+	ts.Projects = projs
+	ts.reindex()
+	ts.loaded = true
+	if wasLoaded {
+		for id, t := range ts.index.tasks {
+			if ot, ok := old.tasks[id]; ok {
+				ts.rememberBase(ot, t)
+			}
+		}
+		ts.emitModelDiff(old, completed)
 	}
-	file, err := os.Create("state.json")
-	if err != nil {
-		return err
+}
+
+// ensureLoaded performs the initial fetch of a lazily loaded state. The
+// caller must hold the lock but not syncMu; the lock is released while
+// downloading.
+func (ts *TickTickState) ensureLoaded() error {
+	if ts.loaded {
+		return nil
+	}
+	ts.mu.Unlock()
+	ts.syncMu.Lock()
+	defer ts.syncMu.Unlock()
+	ts.mu.Lock()
+	// Another caller may have loaded the model while the lock was released.
+	if ts.loaded {
+		return nil
+	}
+	return ts.getAll()
+}
+
+// GetProjects returns copies of the projects with their tasks, which stay
+// valid while the state keeps syncing.
+func (ts *TickTickState) GetProjects() ([]*project.Project, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	return ts.copyProjects(), nil
+}
+
+// copyProjects returns deep copies of the projects and their tasks. The caller
+// must hold the lock.
+func (ts *TickTickState) copyProjects() []*project.Project {
+	projs := make([]*project.Project, 0, len(ts.Projects))
+	for _, p := range ts.Projects {
+		pc := *p
+		pc.Tasks = ts.index.collect(ts.index.byProject[p.Id])
+		projs = append(projs, &pc)
+	}
+	return projs
+}
+
+// WriteToJSON saves the state to the configured store, or to state.json in
+// the current directory if there is none.
+func (ts *TickTickState) WriteToJSON() error {
+	if ts.store == nil {
+		return ts.SaveFile(STATE_CACHE_FILENAME)
 	}
-	defer file.Close()
-	_, err = file.Write(data)
-	return err
+	return ts.Save()
 }
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+)
+
+const (
+	STATE_SCHEMA_VERSION = 2
+	STATE_CACHE_FILENAME = "state.json"
+)
+
+// Snapshot is the serialized form of a TickTickState.
+type Snapshot struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	SavedAt       time.Time          `json:"savedAt"`
+	CheckPoint    int64              `json:"checkPoint"`
+	Projects      []*project.Project `json:"projects"`
+	Tags          []string           `json:"tags"`
+	Queue         []QueuedOp         `json:"queue,omitempty"`
+	Filters       []*filter.Filter   `json:"filters,omitempty"`
+	// AccountTags holds the tags of the account where the client lists them,
+	// while Tags names every tag used by a task.
+	AccountTags []*tag.Tag `json:"accountTags,omitempty"`
+}
+
+// StateStore persists snapshots of a TickTickState. LoadSnapshot returns a
+// nil snapshot and no error if nothing has been saved yet.
+type StateStore interface {
+	LoadSnapshot() (*Snapshot, error)
+	SaveSnapshot(s *Snapshot) error
+}
+
+// FileStore is a StateStore keeping the snapshot as JSON in a single file.
+type FileStore struct {
+	Path string
+}
+
+func (fs FileStore) LoadSnapshot() (*Snapshot, error) {
+	file, err := os.Open(fs.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readSnapshot(file)
+}
+
+// SaveSnapshot replaces the file atomically so a crash never leaves a
+// partially written cache behind.
+func (fs FileStore) SaveSnapshot(s *Snapshot) error {
+	dir := filepath.Dir(fs.Path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(fs.Path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := json.NewEncoder(tmp).Encode(s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fs.Path)
+}
+
+// readSnapshot decodes a snapshot, accepting the bare array of projects that
+// WriteToJSON used to write as a version 1 snapshot without a checkpoint.
+func readSnapshot(r io.Reader) (*Snapshot, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var projs []*project.Project
+		if err := json.Unmarshal(trimmed, &projs); err != nil {
+			return nil, err
+		}
+		return &Snapshot{SchemaVersion: 1, Projects: projs}, nil
+	}
+	var s Snapshot
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DefaultCachePath returns the state cache location inside the user's cache
+// directory, e.g. ~/.cache/go-ticktick/state.json on Linux.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, KEYRING_SERVICE, STATE_CACHE_FILENAME), nil
+}
+
+// WithStore makes the state load itself from store on creation, before
+// syncing, and makes Save write to it.
+func WithStore(store StateStore) StateOption {
+	return func(ts *TickTickState) {
+		ts.store = store
+	}
+}
+
+// WithCachePath is WithStore using a FileStore at path.
+func WithCachePath(path string) StateOption {
+	return WithStore(FileStore{Path: path})
+}
+
+// snapshot builds a Snapshot holding copies of the model, so it can be
+// written out after the lock is released. The caller must hold the lock.
+func (ts *TickTickState) snapshot() *Snapshot {
+	tags := make([]string, 0, len(ts.index.byTag))
+	for tag := range ts.index.byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return &Snapshot{
+		SchemaVersion: STATE_SCHEMA_VERSION,
+		SavedAt:       time.Now(),
+		CheckPoint:    ts.checkPoint,
+		Projects:      ts.copyProjects(),
+		Tags:          tags,
+		Queue:         append([]QueuedOp(nil), ts.queue...),
+		Filters:       append([]*filter.Filter(nil), ts.filters...),
+		AccountTags:   copyTags(ts.tags),
+	}
+}
+
+// restore replaces the model with the contents of s. Snapshots from version 1
+// of the schema are accepted, as they only lack the queue. The caller must
+// hold the lock.
+func (ts *TickTickState) restore(s *Snapshot) error {
+	if s.SchemaVersion < 1 || s.SchemaVersion > STATE_SCHEMA_VERSION {
+		return errors.New(
+			fmt.Sprintf(
+				"state cache has schema version %d, expected %d", s.SchemaVersion, STATE_SCHEMA_VERSION,
+			),
+		)
+	}
+	ts.Projects = s.Projects
+	ts.checkPoint = s.CheckPoint
+	ts.queue = s.Queue
+	ts.filters = s.Filters
+	ts.tags = s.AccountTags
+	ts.reindex()
+	ts.loaded = true
+	return nil
+}
+
+func copyTags(tags []*tag.Tag) []*tag.Tag {
+	res := make([]*tag.Tag, 0, len(tags))
+	for _, t := range tags {
+		tc := *t
+		res = append(res, &tc)
+	}
+	return res
+}
+
+func (ts *TickTickState) Snapshot() *Snapshot {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.snapshot()
+}
+
+func (ts *TickTickState) Restore(s *Snapshot) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.restore(s)
+}
+
+func (ts *TickTickState) SaveTo(w io.Writer) error {
+	return json.NewEncoder(w).Encode(ts.Snapshot())
+}
+
+func (ts *TickTickState) LoadFrom(r io.Reader) error {
+	s, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	return ts.Restore(s)
+}
+
+func (ts *TickTickState) SaveFile(path string) error {
+	return FileStore{Path: path}.SaveSnapshot(ts.Snapshot())
+}
+
+func (ts *TickTickState) LoadFile(path string) error {
+	s, err := FileStore{Path: path}.LoadSnapshot()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return os.ErrNotExist
+	}
+	return ts.Restore(s)
+}
+
+// Save writes the state to the store given with WithStore or WithCachePath.
+func (ts *TickTickState) Save() error {
+	if ts.store == nil {
+		return errors.New("state has no store configured")
+	}
+	return ts.store.SaveSnapshot(ts.Snapshot())
+}
+
+// loadStore restores the state from the configured store, if it holds a
+// snapshot.
+func (ts *TickTickState) loadStore() (bool, error) {
+	if ts.store == nil {
+		return false, nil
+	}
+	s, err := ts.store.LoadSnapshot()
+	if err != nil || s == nil {
+		return false, err
+	}
+	if err := ts.Restore(s); err != nil {
+		return false, err
+	}
+	return true, nil
+}
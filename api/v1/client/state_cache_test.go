@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+type offlineIncrementalClient struct {
+	*fakeStateClient
+}
+
+func (f *offlineIncrementalClient) Sync(ctx context.Context, checkPoint int64) (*v2.SyncResponse, error) {
+	return nil, errors.New("offline")
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	ts := newIndexedTestState(t)
+	ts.checkPoint = 42
+
+	var buf bytes.Buffer
+	if err := ts.SaveTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewTickTickState(newFakeStateClient(), WithLazyLoad())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.LoadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CheckPoint() != 42 {
+		t.Fatalf("Expected checkpoint 42, got %d", loaded.CheckPoint())
+	}
+	task, ok := loaded.TaskByID("t1")
+	if !ok {
+		t.Fatal("Expected t1 in loaded state")
+	}
+	if task.Title != "Write report" || len(task.Tags) != 1 {
+		t.Fatalf("Expected t1 to round trip, got %+v", task)
+	}
+
+	if err := loaded.LoadFrom(bytes.NewBufferString(`{"schemaVersion": 99}`)); err == nil {
+		t.Fatal("Expected unknown schema version to be rejected")
+	}
+}
+
+func TestStateLoadLegacyCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), STATE_CACHE_FILENAME)
+	legacy := `[{"id":"p1","name":"Work","tasks":[{"id":"t1","projectId":"p1","title":"Write report","tags":["work"]}]}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := NewTickTickState(newFakeStateClient(), WithLazyLoad())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	task, ok := ts.TaskByID("t1")
+	if !ok || task.Title != "Write report" || len(ts.TasksWithTag("work")) != 1 {
+		t.Fatalf("Expected t1 from the legacy cache, got %+v", task)
+	}
+	if ts.CheckPoint() != 0 {
+		t.Errorf("Expected a legacy cache to have no checkpoint, got %d", ts.CheckPoint())
+	}
+}
+
+func TestStateStartsFromCacheOffline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", STATE_CACHE_FILENAME)
+	ts := newIndexedTestState(t)
+	ts.checkPoint = 42
+	if err := ts.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	fc := &offlineIncrementalClient{fakeStateClient: newFakeStateClient()}
+	cached, err := NewTickTickState(fc, WithCachePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cached.TaskByID("t3"); !ok {
+		t.Fatal("Expected state to start from the cache")
+	}
+
+	task := tasks.Task{Title: "New task", ProjectId: "p1"}
+	if err := cached.CreateTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	if err := cached.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewTickTickState(fc, WithCachePath(path), WithLazyLoad())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reloaded.TaskByID(task.Id); !ok {
+		t.Fatal("Expected saved task in the reloaded cache")
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// ConflictError is returned when a task is updated from a copy that is older
+// than the server's. Base is the version the local copy was read from, if it
+// is still known, and has an empty Id otherwise.
+type ConflictError struct {
+	Base   tasks.Task
+	Local  tasks.Task
+	Server tasks.Task
+	// Fields lists the fields changed on both sides, if known.
+	Fields []string
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Fields) > 0 {
+		return fmt.Sprintf(
+			"task %s was changed on the server since it was read, conflicting on %v", e.Local.Id, e.Fields,
+		)
+	}
+	return fmt.Sprintf("task %s was changed on the server since it was read", e.Local.Id)
+}
+
+// ConflictResolver decides the task to store when local was edited from base
+// while the server copy changed to server. Returning an error, typically a
+// *ConflictError, aborts the update.
+type ConflictResolver func(base, local, server tasks.Task) (tasks.Task, error)
+
+// FailOnConflict refuses to update a task changed on the server since it was
+// read, returning a *ConflictError. It is the safe choice when the caller
+// cannot tell which copy should win: nothing is overwritten, and the error
+// holds both copies to resolve by hand.
+func FailOnConflict(base, local, server tasks.Task) (tasks.Task, error) {
+	return tasks.Task{}, &ConflictError{Base: base, Local: local, Server: server}
+}
+
+// ServerWins discards the local changes.
+func ServerWins(base, local, server tasks.Task) (tasks.Task, error) {
+	return server, nil
+}
+
+// ClientWins overwrites the server copy with the local one.
+func ClientWins(base, local, server tasks.Task) (tasks.Task, error) {
+	local.Etag = server.Etag
+	local.ModifiedTime = server.ModifiedTime
+	return local, nil
+}
+
+// ThreeWayMerge keeps every field changed on only one side since base. It
+// fails with a *ConflictError if a field was changed differently on both
+// sides, or if base is unknown and the copies differ at all.
+func ThreeWayMerge(base, local, server tasks.Task) (tasks.Task, error) {
+	merged := server
+	mv := reflect.ValueOf(&merged).Elem()
+	bv, lv, sv := reflect.ValueOf(base), reflect.ValueOf(local), reflect.ValueOf(server)
+	var conflicts []string
+	for i := 0; i < mv.NumField(); i++ {
+		name := mv.Type().Field(i).Name
+		if name == "Etag" || name == "ModifiedTime" {
+			continue
+		}
+		bf, lf, sf := bv.Field(i).Interface(), lv.Field(i).Interface(), sv.Field(i).Interface()
+		switch {
+		case fieldEqual(lf, sf):
+		case base.Id != "" && fieldEqual(sf, bf):
+			mv.Field(i).Set(lv.Field(i))
+		case base.Id != "" && fieldEqual(lf, bf):
+		default:
+			conflicts = append(conflicts, name)
+		}
+	}
+	if len(conflicts) > 0 {
+		return tasks.Task{}, &ConflictError{Base: base, Local: local, Server: server, Fields: conflicts}
+	}
+	return merged.Clone(), nil
+}
+
+// WithConflictResolver sets how UpdateTask and the replay of queued updates
+// handle tasks changed on the server since they were read. Without one,
+// updates are sent as they are and silently overwrite the server copy; use
+// FailOnConflict to have them fail instead.
+//
+// The check is best-effort: the server copy is fetched before the update is
+// sent, so a change made on the server in between is still overwritten.
+func WithConflictResolver(r ConflictResolver) StateOption {
+	return func(ts *TickTickState) {
+		ts.resolver = r
+	}
+}
+
+// changedSince reports whether server is a newer version than the one local
+// was read from, comparing etags if both have one and modification times
+// otherwise. Tasks without either are never considered conflicting.
+func changedSince(local, server *tasks.Task) bool {
+	if local.Etag != "" && server.Etag != "" {
+		return local.Etag != server.Etag
+	}
+	if !local.ModifiedTime.IsZero() && !server.ModifiedTime.IsZero() {
+		return server.ModifiedTime.After(local.ModifiedTime)
+	}
+	return false
+}
+
+// serverCopy fetches the current server version of task, looking its
+// project up in the model if task has none. The caller must hold the lock.
+func (ts *TickTickState) serverCopy(task *tasks.Task) (*tasks.Task, error) {
+	server := tasks.Task{Id: task.Id, ProjectId: task.ProjectId}
+	if t, ok := ts.index.tasks[task.Id]; ok && server.ProjectId == "" {
+		server.ProjectId = t.ProjectId
+	}
+	if err := ts.client.GetTask(&server); err != nil {
+		return nil, err
+	}
+	return &server, nil
+}
+
+// updateTask sends task to the server after checking that it was not changed
+// there since task was read, resolving a conflict if there is one. If the
+// resolution is the server copy, nothing is sent. Without a resolver, task is
+// sent unchecked. The caller must hold the lock.
+func (ts *TickTickState) updateTask(task *tasks.Task) error {
+	if ts.resolver == nil || task.Etag == "" && task.ModifiedTime.IsZero() {
+		return ts.client.UpdateTask(task)
+	}
+	server, err := ts.serverCopy(task)
+	if err != nil {
+		return err
+	}
+	if !changedSince(task, server) {
+		return ts.client.UpdateTask(task)
+	}
+	base, ok := ts.bases[task.Id]
+	if !ok || base.Etag != task.Etag {
+		base = tasks.Task{}
+	}
+	resolved, err := ts.resolver(base, task.Clone(), server.Clone())
+	if err != nil {
+		return err
+	}
+	*task = resolved
+	if len(diffTasks(server, task)) == 0 {
+		return nil
+	}
+	return ts.client.UpdateTask(task)
+}
+
+// rememberBase keeps old as the base for three-way merges when a newer
+// server version of the task replaces it in the model. Tasks with queued
+// changes are skipped, as the model holds the local version of them rather
+// than a server one. The caller must hold the lock.
+func (ts *TickTickState) rememberBase(old, new *tasks.Task) {
+	if old.Etag == "" || old.Etag == new.Etag || ts.hasQueuedOp(old.Id) {
+		return
+	}
+	ts.setBase(old)
+}
+
+func (ts *TickTickState) setBase(t *tasks.Task) {
+	if ts.bases == nil {
+		ts.bases = map[string]tasks.Task{}
+	}
+	ts.bases[t.Id] = t.Clone()
+}
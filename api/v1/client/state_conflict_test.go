@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+type versionedClient struct {
+	*flakyStateClient
+	responses []*v2.SyncResponse
+	updates   []tasks.Task
+	gets      int
+	// server holds the current server version of each task.
+	server map[string]tasks.Task
+}
+
+func (c *versionedClient) Sync(ctx context.Context, checkPoint int64) (*v2.SyncResponse, error) {
+	if err := c.netErr(); err != nil {
+		return nil, err
+	}
+	sr := c.responses[0]
+	c.responses = c.responses[1:]
+	return sr, nil
+}
+
+func (c *versionedClient) GetTask(task *tasks.Task) error {
+	if err := c.netErr(); err != nil {
+		return err
+	}
+	c.gets++
+	server := c.server[task.Id]
+	*task = server.Clone()
+	return nil
+}
+
+func (c *versionedClient) UpdateTask(task *tasks.Task) error {
+	if err := c.netErr(); err != nil {
+		return err
+	}
+	task.Etag = "updated"
+	c.updates = append(c.updates, task.Clone())
+	c.server[task.Id] = task.Clone()
+	return nil
+}
+
+// newVersionedState returns a state synced with t1 at etag a, a copy of t1 as
+// read then, and the client, which has since changed t1's title to etag b.
+func newVersionedState(t *testing.T, opts ...StateOption) (*TickTickState, tasks.Task, *versionedClient) {
+	vc := &versionedClient{
+		flakyStateClient: &flakyStateClient{fakeStateClient: newFakeStateClient()},
+		responses: []*v2.SyncResponse{
+			{
+				CheckPoint:      100,
+				InboxId:         "inbox123456",
+				ProjectProfiles: fullSyncResponse().ProjectProfiles,
+				SyncTaskBean: v2.SyncTaskBean{
+					Update: []tasks.Task{{Id: "t1", ProjectId: "p1", Title: "Write report", Etag: "a"}},
+				},
+			},
+			{
+				CheckPoint: 200,
+				SyncTaskBean: v2.SyncTaskBean{
+					Update: []tasks.Task{{Id: "t1", ProjectId: "p1", Title: "Write the report", Etag: "b"}},
+				},
+			},
+		},
+	}
+	vc.server = map[string]tasks.Task{"t1": vc.responses[1].SyncTaskBean.Update[0]}
+	ts, err := NewTickTickState(vc, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, ok := ts.TaskByID("t1")
+	if !ok {
+		t.Fatal("Expected t1 in the model")
+	}
+	return ts, local, vc
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	base := tasks.Task{Id: "t1", Title: "Report", Content: "draft", Etag: "a"}
+	testCases := []struct {
+		name    string
+		base    tasks.Task
+		local   tasks.Task
+		server  tasks.Task
+		want    tasks.Task
+		wantErr []string
+	}{
+		{
+			name:   "Different fields",
+			base:   base,
+			local:  tasks.Task{Id: "t1", Title: "Report", Content: "final", Etag: "a"},
+			server: tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "b"},
+			want:   tasks.Task{Id: "t1", Title: "Q4 report", Content: "final", Etag: "b"},
+		},
+		{
+			name:   "Same change",
+			base:   base,
+			local:  tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "a"},
+			server: tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "b"},
+			want:   tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "b"},
+		},
+		{
+			name:    "Same field",
+			base:    base,
+			local:   tasks.Task{Id: "t1", Title: "Annual report", Content: "draft", Etag: "a"},
+			server:  tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "b"},
+			wantErr: []string{"Title"},
+		},
+		{
+			name:    "Unknown base",
+			local:   tasks.Task{Id: "t1", Title: "Report", Content: "final", Etag: "a"},
+			server:  tasks.Task{Id: "t1", Title: "Q4 report", Content: "draft", Etag: "b"},
+			wantErr: []string{"Title", "Content"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				got, err := ThreeWayMerge(tc.base, tc.local, tc.server)
+				if tc.wantErr != nil {
+					var ce *ConflictError
+					if !errors.As(err, &ce) || !reflect.DeepEqual(ce.Fields, tc.wantErr) {
+						t.Fatalf("Expected conflict on %v, got %v", tc.wantErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(diffTasks(&tc.want, &got)) != 0 {
+					t.Errorf("Expected %+v, got %+v", tc.want, got)
+				}
+			},
+		)
+	}
+}
+
+func TestStateUpdateConflict(t *testing.T) {
+	testCases := []struct {
+		name     string
+		resolver ConflictResolver
+		sent     bool
+		want     tasks.Task
+	}{
+		{"Server wins", ServerWins, false, tasks.Task{Title: "Write the report"}},
+		{"Client wins", ClientWins, true, tasks.Task{Title: "Write report", Content: "notes"}},
+		{"Three way merge", ThreeWayMerge, true, tasks.Task{Title: "Write the report", Content: "notes"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				ts, local, vc := newVersionedState(t, WithConflictResolver(tc.resolver))
+				if err := ts.Sync(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				local.Content = "notes"
+				if err := ts.UpdateTask(&local); err != nil {
+					t.Fatal(err)
+				}
+				if sent := len(vc.updates) > 0; sent != tc.sent {
+					t.Fatalf("Expected update sent to be %t, got %t", tc.sent, sent)
+				}
+				got, _ := ts.TaskByID("t1")
+				if got.Title != tc.want.Title || got.Content != tc.want.Content {
+					t.Errorf("Expected %q %q, got %q %q", tc.want.Title, tc.want.Content, got.Title, got.Content)
+				}
+			},
+		)
+	}
+
+	ts, local, vc := newVersionedState(t, WithConflictResolver(FailOnConflict))
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	local.Content = "notes"
+	var ce *ConflictError
+	if err := ts.UpdateTask(&local); !errors.As(err, &ce) {
+		t.Fatalf("Expected a conflict error, got %v", err)
+	}
+	if ce.Base.Etag != "a" || ce.Server.Etag != "b" || len(vc.updates) != 0 {
+		t.Fatalf("Expected base a and server b without an update, got %+v", ce)
+	}
+	if got, _ := ts.TaskByID("t1"); got.Content == "notes" {
+		t.Errorf("Expected a failed update to leave the model alone, got %+v", got)
+	}
+
+	ts, local, vc = newVersionedState(t)
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	local.Content = "notes"
+	if err := ts.UpdateTask(&local); err != nil {
+		t.Fatal(err)
+	}
+	if vc.gets != 0 || len(vc.updates) != 1 {
+		t.Fatalf("Expected the update to be sent unchecked without a resolver, got %d fetches and %d updates", vc.gets, len(vc.updates))
+	}
+}
+
+func TestStateReplayResolvesConflicts(t *testing.T) {
+	ts, local, vc := newVersionedState(t, WithOfflineQueue(), WithConflictResolver(ThreeWayMerge))
+	vc.offline = true
+	local.Content = "notes"
+	if err := ts.UpdateTask(&local); err != nil {
+		t.Fatal(err)
+	}
+
+	vc.offline = false
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(vc.updates) != 1 {
+		t.Fatalf("Expected the queued update to be replayed once, got %d", len(vc.updates))
+	}
+	got, _ := ts.TaskByID("t1")
+	if got.Title != "Write the report" || got.Content != "notes" {
+		t.Errorf("Expected the server title and local content, got %+v", got)
+	}
+}
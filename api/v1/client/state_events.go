@@ -0,0 +1,332 @@
+package client
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+const DEFAULT_EVENT_BUFFER = 64
+
+type EventType int
+
+const (
+	TaskCreated EventType = iota
+	TaskUpdated
+	TaskCompleted
+	TaskDeleted
+	ProjectChanged
+)
+
+func (e EventType) String() string {
+	switch e {
+	case TaskCreated:
+		return "TaskCreated"
+	case TaskUpdated:
+		return "TaskUpdated"
+	case TaskCompleted:
+		return "TaskCompleted"
+	case TaskDeleted:
+		return "TaskDeleted"
+	case ProjectChanged:
+		return "ProjectChanged"
+	default:
+		return ""
+	}
+}
+
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// Event describes a change to the model. Task is set for task events, holding
+// the last known copy for TaskCompleted and TaskDeleted; Changes lists the
+// fields that differ for TaskUpdated. Project is set for ProjectChanged, with
+// Deleted reporting whether the project was removed.
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Task    tasks.Task
+	Changes []FieldChange
+	Project project.Project
+	Deleted bool
+}
+
+// Changed reports whether the named field is among the event's changes.
+func (e Event) Changed(field string) bool {
+	for _, c := range e.Changes {
+		if c.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+type BackpressurePolicy int
+
+const (
+	// Block makes the state wait for the subscriber to receive each event.
+	Block BackpressurePolicy = iota
+	// DropNewest discards events that arrive while the buffer is full.
+	DropNewest
+	// DropOldest discards the oldest buffered event to make room.
+	DropOldest
+)
+
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	done    chan struct{}
+	types   map[EventType]bool
+	policy  BackpressurePolicy
+	buffer  int
+	dropped atomic.Int64
+	mu      sync.Mutex
+	closed  bool
+	once    sync.Once
+	state   *TickTickState
+}
+
+type SubscribeOption func(*Subscription)
+
+func WithEventBuffer(n int) SubscribeOption {
+	return func(s *Subscription) {
+		s.buffer = n
+	}
+}
+
+func WithBackpressure(p BackpressurePolicy) SubscribeOption {
+	return func(s *Subscription) {
+		s.policy = p
+	}
+}
+
+// WithEventTypes limits the subscription to the given event types.
+func WithEventTypes(types ...EventType) SubscribeOption {
+	return func(s *Subscription) {
+		s.types = map[EventType]bool{}
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+}
+
+// Subscribe returns a subscription delivering model changes on its C
+// channel. Events are buffered (DEFAULT_EVENT_BUFFER by default) and, once
+// the buffer is full, handled according to the backpressure policy, which
+// defaults to DropOldest.
+func (ts *TickTickState) Subscribe(opts ...SubscribeOption) *Subscription {
+	s := &Subscription{
+		done:   make(chan struct{}),
+		policy: DropOldest,
+		buffer: DEFAULT_EVENT_BUFFER,
+		state:  ts,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ch = make(chan Event, s.buffer)
+	s.C = s.ch
+	ts.subMu.Lock()
+	ts.subscribers = append(ts.subscribers, s)
+	ts.subMu.Unlock()
+	return s
+}
+
+// SubscribeFunc calls fn for each event from a dedicated goroutine until the
+// subscription is closed.
+func (ts *TickTickState) SubscribeFunc(fn func(Event), opts ...SubscribeOption) *Subscription {
+	s := ts.Subscribe(opts...)
+	go func() {
+		for e := range s.C {
+			fn(e)
+		}
+	}()
+	return s
+}
+
+// Dropped returns the number of events discarded because of backpressure.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivery and closes C.
+func (s *Subscription) Close() {
+	s.once.Do(
+		func() {
+			close(s.done)
+			s.state.subMu.Lock()
+			for i, sub := range s.state.subscribers {
+				if sub == s {
+					s.state.subscribers = append(s.state.subscribers[:i], s.state.subscribers[i+1:]...)
+					break
+				}
+			}
+			s.state.subMu.Unlock()
+			s.mu.Lock()
+			s.closed = true
+			close(s.ch)
+			s.mu.Unlock()
+		},
+	)
+}
+
+func (s *Subscription) send(e Event) {
+	if s.types != nil && !s.types[e.Type] {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	switch s.policy {
+	case Block:
+		select {
+		case s.ch <- e:
+		case <-s.done:
+		}
+	case DropNewest:
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	}
+}
+
+func (ts *TickTickState) hasSubscribers() bool {
+	ts.subMu.Lock()
+	defer ts.subMu.Unlock()
+	return len(ts.subscribers) > 0
+}
+
+// emit queues an event for delivery once the lock is released. The caller
+// must hold the lock.
+func (ts *TickTickState) emit(e Event) {
+	if !ts.hasSubscribers() {
+		return
+	}
+	e.Time = time.Now()
+	ts.pendingEvents = append(ts.pendingEvents, e)
+}
+
+// flushEvents delivers the queued events. It must be called without holding
+// the lock, so subscribers may use the state while handling events.
+func (ts *TickTickState) flushEvents() {
+	ts.mu.Lock()
+	pending := ts.pendingEvents
+	ts.pendingEvents = nil
+	ts.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	ts.subMu.Lock()
+	subs := append([]*Subscription(nil), ts.subscribers...)
+	ts.subMu.Unlock()
+	for _, e := range pending {
+		for _, s := range subs {
+			s.send(e)
+		}
+	}
+}
+
+func fieldEqual(a, b interface{}) bool {
+	if at, ok := a.(time.Time); ok {
+		return at.Equal(b.(time.Time))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func diffTasks(old, new *tasks.Task) []FieldChange {
+	var changes []FieldChange
+	ov, nv := reflect.ValueOf(*old), reflect.ValueOf(*new)
+	for i := 0; i < ov.NumField(); i++ {
+		of, nf := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if fieldEqual(of, nf) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: ov.Type().Field(i).Name, Old: of, New: nf})
+	}
+	return changes
+}
+
+func projectChanged(old, new *project.Project) bool {
+	oc, nc := *old, *new
+	oc.Tasks, nc.Tasks = nil, nil
+	return !reflect.DeepEqual(oc, nc)
+}
+
+// emitTaskPut queues the event for storing new over old, which is nil for a
+// new task. The caller must hold the lock.
+func (ts *TickTickState) emitTaskPut(old *tasks.Task, new tasks.Task) {
+	if old == nil {
+		ts.emit(Event{Type: TaskCreated, Task: new.Clone()})
+		return
+	}
+	changes := diffTasks(old, &new)
+	if len(changes) == 0 {
+		return
+	}
+	ts.emit(Event{Type: TaskUpdated, Task: new.Clone(), Changes: changes})
+}
+
+// emitModelDiff queues events for the differences between the index before a
+// full reload and the current one. Tasks that disappear are reported as
+// completed if their ids are in completed and as deleted otherwise, as not
+// every reload says whether they were completed. The caller must hold the
+// lock.
+func (ts *TickTickState) emitModelDiff(old *stateIndex, completed map[string]bool) {
+	for id, t := range ts.index.tasks {
+		ot, ok := old.tasks[id]
+		if !ok {
+			ts.emitTaskPut(nil, *t)
+			continue
+		}
+		ts.emitTaskPut(ot, *t)
+	}
+	for id, t := range old.tasks {
+		if _, ok := ts.index.tasks[id]; ok {
+			continue
+		}
+		if completed[id] {
+			ts.emit(Event{Type: TaskCompleted, Task: t.Clone()})
+		} else {
+			ts.emit(Event{Type: TaskDeleted, Task: t.Clone()})
+		}
+	}
+	for id, p := range ts.index.projects {
+		op, ok := old.projects[id]
+		if !ok || projectChanged(op, p) {
+			pc := *p
+			pc.Tasks = nil
+			ts.emit(Event{Type: ProjectChanged, Project: pc})
+		}
+	}
+	for id, p := range old.projects {
+		if _, ok := ts.index.projects[id]; !ok {
+			pc := *p
+			pc.Tasks = nil
+			ts.emit(Event{Type: ProjectChanged, Project: pc, Deleted: true})
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+func nextEvent(t *testing.T, s *Subscription) Event {
+	t.Helper()
+	select {
+	case e := <-s.C:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for event")
+	}
+	return Event{}
+}
+
+func TestStateEventsFromSync(t *testing.T) {
+	due := time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local)
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses: []*v2.SyncResponse{
+			fullSyncResponse(),
+			{
+				CheckPoint: 200,
+				SyncTaskBean: v2.SyncTaskBean{
+					Add: []tasks.Task{{Id: "t3", ProjectId: "p1", Title: "Review"}},
+					Update: []tasks.Task{
+						{Id: "t1", ProjectId: "p1", Title: "Write report", DueDate: due},
+						{Id: "t2", ProjectId: "inbox123456", Title: "Buy milk", Status: tasks.Completed},
+					},
+				},
+			},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := ts.Subscribe()
+	defer sub.Close()
+
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	e := nextEvent(t, sub)
+	if e.Type != TaskCreated || e.Task.Id != "t3" {
+		t.Fatalf("Expected TaskCreated for t3, got %s for %s", e.Type, e.Task.Id)
+	}
+	e = nextEvent(t, sub)
+	if e.Type != TaskUpdated || e.Task.Id != "t1" || !e.Changed("DueDate") || len(e.Changes) != 1 {
+		t.Fatalf("Expected TaskUpdated for t1 with a DueDate change, got %s %+v", e.Type, e.Changes)
+	}
+	e = nextEvent(t, sub)
+	if e.Type != TaskCompleted || e.Task.Title != "Buy milk" {
+		t.Fatalf("Expected TaskCompleted for t2, got %s for %s", e.Type, e.Task.Id)
+	}
+}
+
+func TestStateEventFilterAndBackpressure(t *testing.T) {
+	ts := newIndexedTestState(t)
+	sub := ts.Subscribe(WithEventTypes(TaskCreated), WithEventBuffer(1), WithBackpressure(DropNewest))
+	defer sub.Close()
+
+	for i := 0; i < 3; i++ {
+		task := tasks.Task{Title: "New task", ProjectId: "p1"}
+		if err := ts.CreateTask(&task); err != nil {
+			t.Fatal(err)
+		}
+		if err := ts.CompleteTask(&task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e := nextEvent(t, sub)
+	if e.Type != TaskCreated || e.Task.Id != "created1" {
+		t.Fatalf("Expected the first TaskCreated, got %s for %s", e.Type, e.Task.Id)
+	}
+	if sub.Dropped() != 2 {
+		t.Fatalf("Expected 2 dropped events, got %d", sub.Dropped())
+	}
+}
+
+func TestStateSubscribeFunc(t *testing.T) {
+	ts := newIndexedTestState(t)
+	got := make(chan Event, 1)
+	sub := ts.SubscribeFunc(
+		func(e Event) {
+			got <- e
+		}, WithBackpressure(Block),
+	)
+	defer sub.Close()
+
+	task, _ := ts.TaskByID("t1")
+	if err := ts.DeleteTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-got:
+		if e.Type != TaskDeleted || e.Task.Id != "t1" {
+			t.Fatalf("Expected TaskDeleted for t1, got %s for %s", e.Type, e.Task.Id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for callback")
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// Filters returns copies of the saved filters of the account. They are only
+// known when the client supports incremental sync, as the v1 API does not
+// expose them.
+func (ts *TickTickState) Filters() ([]filter.Filter, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	fs := make([]filter.Filter, 0, len(ts.filters))
+	for _, f := range ts.filters {
+		fs = append(fs, *f)
+	}
+	return fs, nil
+}
+
+// FilterTasks returns the tasks in the model matching f, ordered by its sort
+// type, the way the app lists them in the filter's smart list.
+func (ts *TickTickState) FilterTasks(f *filter.Filter) ([]tasks.Task, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	ids := make(idSet, len(ts.index.tasks))
+	for id := range ts.index.tasks {
+		ids.add(id)
+	}
+	env := &filter.Env{
+		ProjectGroup: func(id string) string {
+			if p, ok := ts.index.projects[id]; ok {
+				return p.GroupId
+			}
+			return ""
+		},
+		ProjectName: func(id string) string {
+			if p, ok := ts.index.projects[id]; ok {
+				return p.Name
+			}
+			return ""
+		},
+	}
+	return f.Apply(ts.index.collect(ids), env), nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+func TestStateFilters(t *testing.T) {
+	work := &filter.Filter{
+		Id:       "f1",
+		Name:     "Work group",
+		Rule:     filter.Rule{And: []filter.Condition{{Name: filter.GroupCondition, Or: filter.Values{"g1"}}}},
+		SortType: "title",
+	}
+	full := fullSyncResponse()
+	full.ProjectProfiles = []*project.Project{{Id: "p1", Name: "Work", GroupId: "g1"}}
+	full.SyncTaskBean.Update = append(full.SyncTaskBean.Update, tasks.Task{Id: "t3", ProjectId: "p1", Title: "Call"})
+	full.Filters = []*filter.Filter{work}
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses: []*v2.SyncResponse{
+			full,
+			{CheckPoint: 200},
+			{
+				CheckPoint: 300,
+				Filters:    []*filter.Filter{work, {Id: "f2", Name: "Inbox"}},
+			},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ts.FilterTasks(work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Id != "t3" || got[1].Id != "t1" {
+		t.Fatalf("Expected t3 and t1, got %+v", got)
+	}
+
+	for _, want := range []int{1, 2} {
+		if err := ts.Sync(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		fs, err := ts.Filters()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fs) != want {
+			t.Fatalf("Expected %d filters, got %d", want, len(fs))
+		}
+	}
+}
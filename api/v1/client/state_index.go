@@ -0,0 +1,228 @@
+package client
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+const DUE_BUCKET_FORMAT = "2006-01-02"
+
+type idSet map[string]struct{}
+
+func (s idSet) add(id string) {
+	s[id] = struct{}{}
+}
+
+type stateIndex struct {
+	tasks     map[string]*tasks.Task
+	projects  map[string]*project.Project
+	byProject map[string]idSet
+	byTag     map[string]idSet
+	byDue     map[string]idSet
+	byParent  map[string]idSet
+}
+
+func newStateIndex() *stateIndex {
+	return &stateIndex{
+		tasks:     map[string]*tasks.Task{},
+		projects:  map[string]*project.Project{},
+		byProject: map[string]idSet{},
+		byTag:     map[string]idSet{},
+		byDue:     map[string]idSet{},
+		byParent:  map[string]idSet{},
+	}
+}
+
+func addToSet(m map[string]idSet, key, id string) {
+	s, ok := m[key]
+	if !ok {
+		s = idSet{}
+		m[key] = s
+	}
+	s.add(id)
+}
+
+func removeFromSet(m map[string]idSet, key, id string) {
+	s, ok := m[key]
+	if !ok {
+		return
+	}
+	delete(s, id)
+	if len(s) == 0 {
+		delete(m, key)
+	}
+}
+
+func dueBucket(t time.Time) string {
+	return t.Local().Format(DUE_BUCKET_FORMAT)
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(tag)
+}
+
+func (idx *stateIndex) addTask(t tasks.Task) {
+	idx.removeTask(t.Id)
+	tc := t.Clone()
+	idx.tasks[t.Id] = &tc
+	addToSet(idx.byProject, t.ProjectId, t.Id)
+	for _, tag := range t.Tags {
+		addToSet(idx.byTag, normalizeTag(tag), t.Id)
+	}
+	if !t.DueDate.IsZero() {
+		addToSet(idx.byDue, dueBucket(t.DueDate), t.Id)
+	}
+	if t.ParentId != "" {
+		addToSet(idx.byParent, t.ParentId, t.Id)
+	}
+}
+
+func (idx *stateIndex) removeTask(id string) {
+	t, ok := idx.tasks[id]
+	if !ok {
+		return
+	}
+	delete(idx.tasks, id)
+	removeFromSet(idx.byProject, t.ProjectId, id)
+	for _, tag := range t.Tags {
+		removeFromSet(idx.byTag, normalizeTag(tag), id)
+	}
+	if !t.DueDate.IsZero() {
+		removeFromSet(idx.byDue, dueBucket(t.DueDate), id)
+	}
+	if t.ParentId != "" {
+		removeFromSet(idx.byParent, t.ParentId, id)
+	}
+}
+
+// collect returns copies of the tasks with the given ids ordered by sort
+// order, then id.
+func (idx *stateIndex) collect(ids idSet) []tasks.Task {
+	res := make([]tasks.Task, 0, len(ids))
+	for id := range ids {
+		if t, ok := idx.tasks[id]; ok {
+			res = append(res, t.Clone())
+		}
+	}
+	sortTasks(res)
+	return res
+}
+
+func sortTasks(ts []tasks.Task) {
+	sort.Slice(
+		ts, func(i, j int) bool {
+			if ts[i].SortOrder != ts[j].SortOrder {
+				return ts[i].SortOrder < ts[j].SortOrder
+			}
+			return ts[i].Id < ts[j].Id
+		},
+	)
+}
+
+// reindex rebuilds the index from ts.Projects. The caller must hold the lock.
+func (ts *TickTickState) reindex() {
+	idx := newStateIndex()
+	for _, p := range ts.Projects {
+		idx.projects[p.Id] = p
+		for _, t := range p.Tasks {
+			idx.addTask(t)
+		}
+	}
+	ts.index = idx
+}
+
+// refreshProjectTasks rewrites the Tasks slice of the project with the given
+// id from the index so that ts.Projects stays consistent with it. The caller
+// must hold the lock.
+func (ts *TickTickState) refreshProjectTasks(projectID string) {
+	p, ok := ts.index.projects[projectID]
+	if !ok {
+		return
+	}
+	p.Tasks = ts.index.collect(ts.index.byProject[projectID])
+}
+
+func (ts *TickTickState) TaskByID(id string) (tasks.Task, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return tasks.Task{}, false
+	}
+	t, ok := ts.index.tasks[id]
+	if !ok {
+		return tasks.Task{}, false
+	}
+	return t.Clone(), true
+}
+
+// ProjectByID returns a copy of the project with the given id, including
+// copies of its tasks.
+func (ts *TickTickState) ProjectByID(id string) (project.Project, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return project.Project{}, false
+	}
+	p, ok := ts.index.projects[id]
+	if !ok {
+		return project.Project{}, false
+	}
+	pc := *p
+	pc.Tasks = ts.index.collect(ts.index.byProject[id])
+	return pc, true
+}
+
+func (ts *TickTickState) TasksInProject(projectID string) []tasks.Task {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil
+	}
+	return ts.index.collect(ts.index.byProject[projectID])
+}
+
+// TasksWithTag returns the tasks tagged with tag, compared case-insensitively.
+func (ts *TickTickState) TasksWithTag(tag string) []tasks.Task {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil
+	}
+	return ts.index.collect(ts.index.byTag[normalizeTag(tag)])
+}
+
+// TasksDueBetween returns the tasks with a due date in [start, end].
+func (ts *TickTickState) TasksDueBetween(start, end time.Time) []tasks.Task {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil
+	}
+	ids := idSet{}
+	first, last := dueBucket(start), dueBucket(end)
+	for bucket, bucketIDs := range ts.index.byDue {
+		if bucket < first || bucket > last {
+			continue
+		}
+		for id := range bucketIDs {
+			due := ts.index.tasks[id].DueDate
+			if !due.Before(start) && !due.After(end) {
+				ids.add(id)
+			}
+		}
+	}
+	return ts.index.collect(ids)
+}
+
+func (ts *TickTickState) Subtasks(parentID string) []tasks.Task {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil
+	}
+	return ts.index.collect(ts.index.byParent[parentID])
+}
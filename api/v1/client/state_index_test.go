@@ -0,0 +1,77 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func newIndexedTestState(t *testing.T) *TickTickState {
+	due := time.Date(2024, 12, 15, 18, 30, 0, 0, time.Local)
+	fc := &fakeStateClient{
+		projects: []*project.Project{
+			{
+				Id:   "p1",
+				Name: "Work",
+				Tasks: []tasks.Task{
+					{Id: "t1", ProjectId: "p1", Title: "Write report", Tags: []string{"Urgent"}, DueDate: due},
+					{Id: "t3", ProjectId: "p1", Title: "Outline", ParentId: "t1", SortOrder: 1},
+				},
+			},
+		},
+		inbox: &project.Project{
+			Id:   "inbox123456",
+			Name: "Inbox",
+			Tasks: []tasks.Task{
+				{Id: "t2", ProjectId: "inbox123456", Title: "Buy milk", DueDate: due.AddDate(0, 0, 3)},
+			},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func TestStateTaskByID(t *testing.T) {
+	ts := newIndexedTestState(t)
+	task, ok := ts.TaskByID("t1")
+	if !ok {
+		t.Fatal("Expected to find task t1")
+	}
+	task.Title = "Changed"
+	task.Tags[0] = "changed"
+
+	again, _ := ts.TaskByID("t1")
+	if again.Title != "Write report" || again.Tags[0] != "Urgent" {
+		t.Fatalf("Expected TaskByID to return a copy, state now has %q %v", again.Title, again.Tags)
+	}
+	if _, ok := ts.TaskByID("missing"); ok {
+		t.Fatal("Expected missing task not to be found")
+	}
+}
+
+func TestStateIndexedAccessors(t *testing.T) {
+	ts := newIndexedTestState(t)
+
+	if got := ts.TasksInProject("p1"); len(got) != 2 || got[0].Id != "t1" {
+		t.Fatalf("Expected tasks t1, t3 in p1, got %v", got)
+	}
+	if got := ts.TasksWithTag("urgent"); len(got) != 1 || got[0].Id != "t1" {
+		t.Fatalf("Expected t1 tagged urgent, got %v", got)
+	}
+	if got := ts.Subtasks("t1"); len(got) != 1 || got[0].Id != "t3" {
+		t.Fatalf("Expected t3 as the subtask of t1, got %v", got)
+	}
+
+	start := time.Date(2024, 12, 15, 0, 0, 0, 0, time.Local)
+	if got := ts.TasksDueBetween(start, start.AddDate(0, 0, 1)); len(got) != 1 || got[0].Id != "t1" {
+		t.Fatalf("Expected only t1 due on the 15th, got %v", got)
+	}
+	if got := ts.TasksDueBetween(start, start.AddDate(0, 0, 7)); len(got) != 2 {
+		t.Fatalf("Expected 2 tasks due that week, got %d", len(got))
+	}
+}
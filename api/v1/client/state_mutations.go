@@ -0,0 +1,187 @@
+package client
+
+import (
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// putTask stores a copy of t in the model, moving it between projects if its
+// project changed. The caller must hold the lock.
+func (ts *TickTickState) putTask(t tasks.Task) {
+	if !ts.loaded {
+		return
+	}
+	ts.generation++
+	oldProjectID := ""
+	old, ok := ts.index.tasks[t.Id]
+	if ok {
+		oldProjectID = old.ProjectId
+		ts.rememberBase(old, &t)
+	}
+	ts.emitTaskPut(old, t)
+	ts.index.addTask(t)
+	if oldProjectID != "" && oldProjectID != t.ProjectId {
+		ts.refreshProjectTasks(oldProjectID)
+	}
+	ts.refreshProjectTasks(t.ProjectId)
+}
+
+// dropTask removes t from the model, queueing an event of type evType with the
+// last known copy of the task. Tasks not in the model are ignored. The caller
+// must hold the lock.
+func (ts *TickTickState) dropTask(t tasks.Task, evType EventType) {
+	if !ts.loaded {
+		return
+	}
+	delete(ts.bases, t.Id)
+	old, ok := ts.index.tasks[t.Id]
+	if !ok {
+		return
+	}
+	ts.generation++
+	ts.emit(Event{Type: evType, Task: old.Clone()})
+	projectID := old.ProjectId
+	ts.index.removeTask(t.Id)
+	ts.refreshProjectTasks(projectID)
+}
+
+// putProject stores p in the model, keeping the tasks already indexed for it.
+// The caller must hold the lock.
+func (ts *TickTickState) putProject(p project.Project) {
+	if !ts.loaded {
+		return
+	}
+	ts.generation++
+	pc := p
+	pc.Tasks = nil
+	if existing, ok := ts.index.projects[p.Id]; ok {
+		if projectChanged(existing, &pc) {
+			ts.emit(Event{Type: ProjectChanged, Project: pc})
+		}
+		p.Tasks = existing.Tasks
+		*existing = p
+		return
+	}
+	ts.emit(Event{Type: ProjectChanged, Project: pc})
+	ts.Projects = append(ts.Projects, &pc)
+	ts.index.projects[pc.Id] = &pc
+	ts.refreshProjectTasks(pc.Id)
+}
+
+// dropProject removes the project with the given id and its tasks from the
+// model. The caller must hold the lock.
+func (ts *TickTickState) dropProject(id string) {
+	if !ts.loaded {
+		return
+	}
+	ts.generation++
+	for taskID := range ts.index.byProject[id] {
+		ts.dropTask(*ts.index.tasks[taskID], TaskDeleted)
+	}
+	if p, ok := ts.index.projects[id]; ok {
+		pc := *p
+		pc.Tasks = nil
+		ts.emit(Event{Type: ProjectChanged, Project: pc, Deleted: true})
+	}
+	delete(ts.index.projects, id)
+	for i, p := range ts.Projects {
+		if p.Id == id {
+			ts.Projects = append(ts.Projects[:i], ts.Projects[i+1:]...)
+			break
+		}
+	}
+}
+
+func (ts *TickTickState) CreateTask(task *tasks.Task) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.runTaskOp(OpCreateTask, task); err != nil {
+		return err
+	}
+	ts.putTask(*task)
+	return nil
+}
+
+func (ts *TickTickState) UpdateTask(task *tasks.Task) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.runTaskOp(OpUpdateTask, task); err != nil {
+		return err
+	}
+	ts.putTask(*task)
+	return nil
+}
+
+// CompleteTask completes the task and removes it from the model, matching the
+// server's project listings, which only contain open tasks.
+func (ts *TickTickState) CompleteTask(task *tasks.Task) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.runTaskOp(OpCompleteTask, task); err != nil {
+		return err
+	}
+	ts.dropTask(*task, TaskCompleted)
+	return nil
+}
+
+func (ts *TickTickState) DeleteTask(task *tasks.Task) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.runTaskOp(OpDeleteTask, task); err != nil {
+		return err
+	}
+	ts.dropTask(*task, TaskDeleted)
+	return nil
+}
+
+func (ts *TickTickState) MoveTask(task *tasks.Task, toProjectID string) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	oldID := task.Id
+	if err := ts.client.MoveTask(task, toProjectID); err != nil {
+		return err
+	}
+	if oldID != task.Id {
+		ts.dropTask(tasks.Task{Id: oldID}, TaskDeleted)
+	}
+	ts.putTask(*task)
+	return nil
+}
+
+func (ts *TickTickState) CreateProject(proj *project.Project) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.client.CreateNewProject(proj); err != nil {
+		return err
+	}
+	ts.putProject(*proj)
+	return nil
+}
+
+func (ts *TickTickState) UpdateProject(proj *project.Project) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.client.UpdateProject(proj); err != nil {
+		return err
+	}
+	ts.putProject(*proj)
+	return nil
+}
+
+func (ts *TickTickState) DeleteProject(id string) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.client.DeleteProjectById(id); err != nil {
+		return err
+	}
+	ts.dropProject(id)
+	return nil
+}
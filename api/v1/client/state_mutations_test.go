@@ -0,0 +1,80 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func TestStateWriteThrough(t *testing.T) {
+	ts := newIndexedTestState(t)
+
+	task := tasks.Task{Title: "New task", ProjectId: "p1", Tags: []string{"home"}}
+	if err := ts.CreateTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.TaskByID(task.Id); !ok {
+		t.Fatal("Expected created task in state")
+	}
+	if got := ts.TasksWithTag("home"); len(got) != 1 {
+		t.Fatalf("Expected created task to be indexed by tag, got %d", len(got))
+	}
+	if len(ts.index.projects["p1"].Tasks) != 3 {
+		t.Fatalf("Expected project tasks to be refreshed, got %d", len(ts.index.projects["p1"].Tasks))
+	}
+
+	task.Tags = nil
+	if err := ts.UpdateTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	if got := ts.TasksWithTag("home"); len(got) != 0 {
+		t.Fatalf("Expected updated task to leave the tag index, got %d", len(got))
+	}
+
+	if err := ts.MoveTask(&task, "inbox123456"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ts.TasksInProject("inbox123456"); len(got) != 2 {
+		t.Fatalf("Expected moved task in the inbox, got %d inbox tasks", len(got))
+	}
+	if got := ts.TasksInProject("p1"); len(got) != 2 {
+		t.Fatalf("Expected moved task to leave p1, got %d tasks", len(got))
+	}
+
+	if err := ts.CompleteTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.TaskByID(task.Id); ok {
+		t.Fatal("Expected completed task to be removed from state")
+	}
+
+	p := project.Project{Name: "Home"}
+	if err := ts.CreateProject(&p); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.ProjectByID(p.Id); !ok || len(ts.Projects) != 3 {
+		t.Fatal("Expected created project in state")
+	}
+	if err := ts.DeleteProject("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.TaskByID("t1"); ok {
+		t.Fatal("Expected tasks of a deleted project to be removed")
+	}
+}
+
+func TestStateWriteThroughFailure(t *testing.T) {
+	ts := newIndexedTestState(t)
+	ts.client.(*fakeStateClient).err = errors.New("offline")
+
+	task, _ := ts.TaskByID("t1")
+	task.Title = "Changed"
+	if err := ts.UpdateTask(&task); err == nil {
+		t.Fatal("Expected update to fail")
+	}
+	if got, _ := ts.TaskByID("t1"); got.Title != "Write report" {
+		t.Fatalf("Expected failed update to leave state unchanged, got %q", got.Title)
+	}
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"github.com/herzs11/go-ticktick/api/v1/query"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// Query returns the tasks in the model matching q, a query in the syntax
+// described by query.Parse, e.g. "project:Work tag:urgent due<=+3d sort:due".
+// Without a sort clause tasks are ordered by sort order.
+func (ts *TickTickState) Query(q string) ([]tasks.Task, error) {
+	parsed, err := query.Parse(q)
+	if err != nil {
+		return nil, err
+	}
+	return ts.Find(parsed)
+}
+
+func (ts *TickTickState) Find(q *query.Query) ([]tasks.Task, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	ids := make(idSet, len(ts.index.tasks))
+	for id := range ts.index.tasks {
+		ids.add(id)
+	}
+	env := &query.Env{
+		ProjectName: func(id string) string {
+			if p, ok := ts.index.projects[id]; ok {
+				return p.Name
+			}
+			return ""
+		},
+	}
+	return q.Apply(ts.index.collect(ids), env), nil
+}
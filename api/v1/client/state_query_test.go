@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestStateQuery(t *testing.T) {
+	ts := newIndexedTestState(t)
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{"project:Work", []string{"t1", "t3"}},
+		{"project:inbox tag:urgent", nil},
+		{"due:2024-12-18", []string{"t2"}},
+		{"!subtask sort:-due", []string{"t2", "t1"}},
+		{"sort:title limit:2", []string{"t2", "t3"}},
+	}
+	for _, tc := range testCases {
+		got, err := ts.Query(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s: Expected %v, got %d tasks", tc.query, tc.want, len(got))
+		}
+		for i := range got {
+			if got[i].Id != tc.want[i] {
+				t.Fatalf("%s: Expected %v, got %s at %d", tc.query, tc.want, got[i].Id, i)
+			}
+		}
+	}
+
+	if _, err := ts.Query("due<"); err == nil {
+		t.Fatal("Expected an invalid query to fail")
+	}
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+// LOCAL_ID_PREFIX marks ids assigned to tasks created while offline. They are
+// replaced by the server's ids once the creation is replayed.
+const LOCAL_ID_PREFIX = "local-"
+
+type OpKind string
+
+const (
+	OpCreateTask   OpKind = "createTask"
+	OpUpdateTask   OpKind = "updateTask"
+	OpCompleteTask OpKind = "completeTask"
+	OpDeleteTask   OpKind = "deleteTask"
+)
+
+// QueuedOp is a task mutation made while offline, waiting to be replayed.
+type QueuedOp struct {
+	Op       OpKind     `json:"op"`
+	Task     tasks.Task `json:"task"`
+	QueuedAt time.Time  `json:"queuedAt"`
+}
+
+// WithOfflineQueue makes task mutations that fail because the server cannot
+// be reached succeed locally instead. They are queued, persisted with the
+// rest of the state if a store is configured, and replayed in order before
+// the next sync.
+func WithOfflineQueue() StateOption {
+	return func(ts *TickTickState) {
+		ts.offline = true
+	}
+}
+
+func IsLocalId(id string) bool {
+	return strings.HasPrefix(id, LOCAL_ID_PREFIX)
+}
+
+func newLocalId() string {
+	h, err := randomHex(12)
+	if err != nil {
+		h = strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return LOCAL_ID_PREFIX + h
+}
+
+// isNetworkError reports whether err means the server could not be reached,
+// as opposed to the server rejecting the request.
+func isNetworkError(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// PendingOps returns the mutations waiting to be replayed, oldest first.
+func (ts *TickTickState) PendingOps() []QueuedOp {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ops := make([]QueuedOp, len(ts.queue))
+	for i, op := range ts.queue {
+		ops[i] = QueuedOp{Op: op.Op, Task: op.Task.Clone(), QueuedAt: op.QueuedAt}
+	}
+	return ops
+}
+
+// ReplayQueue sends the queued mutations to the server in order. It stops at
+// the first network error, keeping that operation and the ones after it for
+// the next attempt. Operations the server rejects are dropped and their
+// errors returned.
+func (ts *TickTickState) ReplayQueue(ctx context.Context) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.replayQueue(ctx)
+}
+
+// runTaskOp sends a task mutation to the server, queueing it instead if the
+// server cannot be reached and the offline queue is enabled. Mutations are
+// also queued while older ones are still waiting, so they reach the server in
+// order once the queue is replayed by Sync or ReplayQueue. The caller must
+// hold the lock.
+func (ts *TickTickState) runTaskOp(op OpKind, task *tasks.Task) error {
+	if id, ok := ts.localIds[task.Id]; ok {
+		task.Id = id
+	}
+	if id, ok := ts.localIds[task.ParentId]; ok {
+		task.ParentId = id
+	}
+	if !ts.offline || len(ts.queue) == 0 {
+		err := ts.sendTaskOp(op, task)
+		if err == nil || !ts.offline || !isNetworkError(err) {
+			return err
+		}
+	}
+	ts.enqueue(op, task)
+	return nil
+}
+
+func (ts *TickTickState) sendTaskOp(op OpKind, task *tasks.Task) error {
+	switch op {
+	case OpCreateTask:
+		return ts.client.CreateTask(task)
+	case OpUpdateTask:
+		return ts.updateTask(task)
+	case OpCompleteTask:
+		return ts.client.CompleteTask(task)
+	case OpDeleteTask:
+		return ts.client.DeleteTask(task)
+	default:
+		return errors.New(fmt.Sprintf("unknown queued operation %q", op))
+	}
+}
+
+// enqueue records op and applies it to task as the server would, so the
+// caller sees the same result as an online mutation. The caller must hold
+// the lock.
+func (ts *TickTickState) enqueue(op OpKind, task *tasks.Task) {
+	switch op {
+	case OpCreateTask:
+		if task.Id == "" {
+			task.Id = newLocalId()
+		}
+		if task.ProjectId == "" {
+			task.ProjectId = ts.inboxID()
+		}
+	case OpUpdateTask:
+		// Keep the server version the update was made from, as the model
+		// will hold the local one until the update is replayed.
+		if t, ok := ts.index.tasks[task.Id]; ok && t.Etag != "" && t.Etag == task.Etag && !ts.hasQueuedOp(task.Id) {
+			ts.setBase(t)
+		}
+	case OpCompleteTask:
+		task.Status = tasks.Completed
+		if task.CompletedTime.IsZero() {
+			task.CompletedTime = time.Now()
+		}
+	}
+	ts.queue = append(ts.queue, QueuedOp{Op: op, Task: task.Clone(), QueuedAt: time.Now()})
+	ts.saveQueue()
+}
+
+// saveQueue writes the state to the store, if there is one, so the queue
+// survives a restart. The caller must hold the lock.
+func (ts *TickTickState) saveQueue() {
+	if ts.store == nil {
+		return
+	}
+	if err := ts.store.SaveSnapshot(ts.snapshot()); err != nil {
+		log.Printf("Could not save queued changes: %s\n", err)
+	}
+}
+
+func (ts *TickTickState) hasQueuedOp(taskID string) bool {
+	for _, op := range ts.queue {
+		if op.Task.Id == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// inboxID returns the id of the inbox project in the model, if it is known.
+// The caller must hold the lock.
+func (ts *TickTickState) inboxID() string {
+	for id := range ts.index.projects {
+		if strings.HasPrefix(id, "inbox") {
+			return id
+		}
+	}
+	return ""
+}
+
+func (ts *TickTickState) replayQueue(ctx context.Context) error {
+	var errs []error
+	for len(ts.queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		op := ts.queue[0]
+		task := op.Task.Clone()
+		if op.Op == OpCreateTask && IsLocalId(task.Id) {
+			task.Id = ""
+		}
+		err := ts.sendTaskOp(op.Op, &task)
+		if isNetworkError(err) {
+			ts.saveQueue()
+			return err
+		}
+		ts.queue = ts.queue[1:]
+		if err != nil {
+			errs = append(errs, fmt.Errorf("replaying %s of task %s: %w", op.Op, op.Task.Id, err))
+			if op.Op == OpCreateTask && IsLocalId(op.Task.Id) {
+				errs = append(errs, ts.dropLocalTask(op.Task)...)
+			}
+			continue
+		}
+		switch op.Op {
+		case OpCreateTask:
+			if task.Id != op.Task.Id {
+				ts.remapLocalId(op.Task.Id, task.Id)
+				ts.dropTask(op.Task, TaskDeleted)
+			}
+			ts.putTask(task)
+		case OpUpdateTask:
+			ts.putTask(task)
+		}
+	}
+	ts.saveQueue()
+	return errors.Join(errs...)
+}
+
+// remapLocalId rewrites the queued operations and subtasks referring to a
+// task created offline to use the id the server assigned to it, and
+// remembers the mapping for callers still holding the local id. The caller
+// must hold the lock.
+func (ts *TickTickState) remapLocalId(localID, serverID string) {
+	if ts.localIds == nil {
+		ts.localIds = map[string]string{}
+	}
+	ts.localIds[localID] = serverID
+	for i := range ts.queue {
+		if ts.queue[i].Task.Id == localID {
+			ts.queue[i].Task.Id = serverID
+		}
+		if ts.queue[i].Task.ParentId == localID {
+			ts.queue[i].Task.ParentId = serverID
+		}
+	}
+	for _, child := range ts.index.collect(ts.index.byParent[localID]) {
+		child.ParentId = serverID
+		ts.putTask(child)
+	}
+}
+
+// dropLocalTask removes a task created offline whose creation the server
+// rejected, along with the queued operations on it, which could never be
+// replayed. Its queued and local subtasks are kept as top-level tasks. The
+// caller must hold the lock.
+func (ts *TickTickState) dropLocalTask(t tasks.Task) []error {
+	var errs []error
+	queue := ts.queue[:0]
+	for _, op := range ts.queue {
+		if op.Task.Id == t.Id {
+			errs = append(errs, errors.New(fmt.Sprintf("dropped queued %s of task %s: its creation was rejected", op.Op, t.Id)))
+			continue
+		}
+		if op.Task.ParentId == t.Id {
+			op.Task.ParentId = ""
+		}
+		queue = append(queue, op)
+	}
+	ts.queue = queue
+	for _, child := range ts.index.collect(ts.index.byParent[t.Id]) {
+		child.ParentId = ""
+		ts.putTask(child)
+	}
+	ts.dropTask(t, TaskDeleted)
+	return errs
+}
+
+// reapplyQueue applies the queued operations to a freshly loaded model, so
+// that changes not yet on the server are not lost from it. The caller must
+// hold the lock.
+func (ts *TickTickState) reapplyQueue() {
+	for _, op := range ts.queue {
+		switch op.Op {
+		case OpCreateTask, OpUpdateTask:
+			if t, ok := ts.index.tasks[op.Task.Id]; ok && t.ProjectId != op.Task.ProjectId {
+				projectID := t.ProjectId
+				ts.index.removeTask(op.Task.Id)
+				ts.refreshProjectTasks(projectID)
+			}
+			ts.index.addTask(op.Task)
+			ts.refreshProjectTasks(op.Task.ProjectId)
+		case OpCompleteTask, OpDeleteTask:
+			if t, ok := ts.index.tasks[op.Task.Id]; ok {
+				projectID := t.ProjectId
+				ts.index.removeTask(op.Task.Id)
+				ts.refreshProjectTasks(projectID)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+type flakyStateClient struct {
+	*fakeStateClient
+	offline bool
+	reject  string
+	calls   int
+}
+
+func (f *flakyStateClient) netErr() error {
+	f.calls++
+	if f.offline {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return nil
+}
+
+func (f *flakyStateClient) CreateTask(task *tasks.Task) error {
+	if err := f.netErr(); err != nil {
+		return err
+	}
+	if f.reject != "" && task.Title == f.reject {
+		return errors.New("invalid task")
+	}
+	return f.fakeStateClient.CreateTask(task)
+}
+
+func (f *flakyStateClient) UpdateTask(task *tasks.Task) error {
+	if err := f.netErr(); err != nil {
+		return err
+	}
+	return f.fakeStateClient.UpdateTask(task)
+}
+
+func (f *flakyStateClient) CompleteTask(task *tasks.Task) error {
+	if err := f.netErr(); err != nil {
+		return err
+	}
+	return f.fakeStateClient.CompleteTask(task)
+}
+
+func TestStateOfflineQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), STATE_CACHE_FILENAME)
+	fc := &flakyStateClient{fakeStateClient: newFakeStateClient(), offline: true}
+	ts, err := NewTickTickState(fc, WithOfflineQueue(), WithCachePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := &tasks.Task{Title: "Call"}
+	if err := ts.CreateTask(call); err != nil {
+		t.Fatalf("Expected offline create to be queued, got %s", err)
+	}
+	if !IsLocalId(call.Id) || call.ProjectId != "inbox123456" {
+		t.Fatalf("Expected a local id in the inbox, got %+v", call)
+	}
+	sub := &tasks.Task{Title: "Find number", ParentId: call.Id, ProjectId: "inbox123456"}
+	if err := ts.CreateTask(sub); err != nil {
+		t.Fatal(err)
+	}
+	t1, _ := ts.TaskByID("t1")
+	if err := ts.CompleteTask(&t1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.TaskByID("t1"); ok {
+		t.Fatal("Expected completed task to leave the model while offline")
+	}
+	if len(ts.PendingOps()) != 3 {
+		t.Fatalf("Expected 3 queued operations, got %d", len(ts.PendingOps()))
+	}
+
+	if err := ts.GetAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.TaskByID(call.Id); !ok {
+		t.Fatal("Expected queued task to survive a full reload")
+	}
+	if _, ok := ts.TaskByID("t1"); ok {
+		t.Fatal("Expected queued completion to survive a full reload")
+	}
+
+	restarted, err := NewTickTickState(fc, WithLazyLoad(), WithOfflineQueue(), WithCachePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted.PendingOps()) != 3 {
+		t.Fatalf("Expected the queue to be persisted, got %d operations", len(restarted.PendingOps()))
+	}
+
+	fc.offline = false
+	if err := ts.ReplayQueue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(ts.PendingOps()) != 0 {
+		t.Fatalf("Expected an empty queue after replay, got %d", len(ts.PendingOps()))
+	}
+	if _, ok := ts.TaskByID(call.Id); ok {
+		t.Fatal("Expected the local id to be replaced")
+	}
+	created, ok := ts.TaskByID("created1")
+	if !ok || created.Title != "Call" {
+		t.Fatalf("Expected created1 to be Call, got %+v", created)
+	}
+	subtasks := ts.Subtasks("created1")
+	if len(subtasks) != 1 || subtasks[0].Id != "created2" {
+		t.Fatalf("Expected created2 as subtask of created1, got %+v", subtasks)
+	}
+
+	call.Title = "Call back"
+	if err := ts.UpdateTask(call); err != nil {
+		t.Fatal(err)
+	}
+	if call.Id != "created1" {
+		t.Fatalf("Expected stale local id to map to created1, got %s", call.Id)
+	}
+}
+
+func TestStateOfflineQueueRejectsServerErrors(t *testing.T) {
+	fc := &flakyStateClient{fakeStateClient: newFakeStateClient()}
+	fc.err = errors.New("invalid task")
+	ts, err := NewTickTickState(fc, WithOfflineQueue())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.UpdateTask(&tasks.Task{Id: "t1", ProjectId: "p1"}); err == nil {
+		t.Fatal("Expected server error to be returned")
+	}
+	if len(ts.PendingOps()) != 0 {
+		t.Fatal("Expected server errors not to be queued")
+	}
+
+	fc.err = nil
+	fc.offline = true
+	withoutQueue, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := withoutQueue.UpdateTask(&tasks.Task{Id: "t1", ProjectId: "p1"}); err == nil {
+		t.Fatal("Expected network error without the offline queue")
+	}
+}
+
+func TestStateOfflineQueueDropsRejectedCreates(t *testing.T) {
+	fc := &flakyStateClient{fakeStateClient: newFakeStateClient(), offline: true, reject: "Bad"}
+	ts, err := NewTickTickState(fc, WithOfflineQueue())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bad := &tasks.Task{Title: "Bad", ProjectId: "p1"}
+	if err := ts.CreateTask(bad); err != nil {
+		t.Fatal(err)
+	}
+	sub := &tasks.Task{Title: "Sub", ParentId: bad.Id, ProjectId: "p1"}
+	if err := ts.CreateTask(sub); err != nil {
+		t.Fatal(err)
+	}
+	bad.Title = "Still bad"
+	if err := ts.UpdateTask(bad); err != nil {
+		t.Fatal(err)
+	}
+	t1, _ := ts.TaskByID("t1")
+	if err := ts.CompleteTask(&t1); err != nil {
+		t.Fatal(err)
+	}
+	if fc.calls != 1 {
+		t.Fatalf("Expected mutations to be queued without replaying the queue, got %d requests", fc.calls)
+	}
+
+	fc.offline = false
+	err = ts.ReplayQueue(context.Background())
+	if err == nil {
+		t.Fatal("Expected the rejected creation to be reported")
+	}
+	if len(ts.PendingOps()) != 0 {
+		t.Fatalf("Expected operations on the rejected task to be dropped, got %+v", ts.PendingOps())
+	}
+	if _, ok := ts.TaskByID(bad.Id); ok {
+		t.Fatal("Expected the rejected task to leave the model")
+	}
+	created, ok := ts.TaskByID("created1")
+	if !ok || created.Title != "Sub" || created.ParentId != "" {
+		t.Fatalf("Expected Sub to be created as a top-level task, got %+v", created)
+	}
+	if _, ok := ts.TaskByID("t1"); ok {
+		t.Fatal("Expected the completion after the rejected task to be replayed")
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	DEFAULT_SYNC_INTERVAL = 5 * time.Minute
+	DEFAULT_MAX_BACKOFF   = time.Hour
+)
+
+// SyncStatus reports the outcome of the most recent syncs.
+type SyncStatus struct {
+	LastAttempt         time.Time
+	LastSuccess         time.Time
+	LastError           error
+	ConsecutiveFailures int
+	Running             bool
+}
+
+// WithSyncInterval sets how often Run syncs, DEFAULT_SYNC_INTERVAL by
+// default. Intervals that are not positive are ignored, as Run would sync
+// in a busy loop.
+func WithSyncInterval(d time.Duration) StateOption {
+	return func(ts *TickTickState) {
+		if d > 0 {
+			ts.syncInterval = d
+		}
+	}
+}
+
+// WithSyncJitter sets the maximum random offset added to or subtracted from
+// each wait in Run, a tenth of the interval by default.
+func WithSyncJitter(d time.Duration) StateOption {
+	return func(ts *TickTickState) {
+		ts.syncJitter = d
+	}
+}
+
+// WithMaxBackoff caps the wait between syncs after repeated failures,
+// DEFAULT_MAX_BACKOFF by default.
+func WithMaxBackoff(d time.Duration) StateOption {
+	return func(ts *TickTickState) {
+		ts.maxBackoff = d
+	}
+}
+
+func (ts *TickTickState) SyncStatus() SyncStatus {
+	ts.runMu.Lock()
+	defer ts.runMu.Unlock()
+	return ts.status
+}
+
+func (ts *TickTickState) recordSync(start time.Time, err error) {
+	ts.runMu.Lock()
+	defer ts.runMu.Unlock()
+	ts.status.LastAttempt = start
+	ts.status.LastError = err
+	if err != nil {
+		ts.status.ConsecutiveFailures++
+		return
+	}
+	ts.status.LastSuccess = time.Now()
+	ts.status.ConsecutiveFailures = 0
+}
+
+// nextSyncDelay returns the wait before the next sync: the interval, doubled
+// for each consecutive failure up to the maximum backoff, plus jitter.
+func (ts *TickTickState) nextSyncDelay() time.Duration {
+	ts.runMu.Lock()
+	failures := ts.status.ConsecutiveFailures
+	ts.runMu.Unlock()
+
+	d := ts.syncInterval
+	for i := 0; i < failures && d < ts.maxBackoff; i++ {
+		d *= 2
+	}
+	if failures > 0 && d > ts.maxBackoff {
+		d = max(ts.maxBackoff, ts.syncInterval)
+	}
+	if ts.syncJitter > 0 {
+		d += time.Duration(rand.Int64N(int64(2*ts.syncJitter)+1)) - ts.syncJitter
+	}
+	return max(d, time.Millisecond)
+}
+
+// Run syncs the state immediately and then periodically until ctx is
+// cancelled, returning ctx.Err(). Failed syncs are logged and retried with
+// exponential backoff. If a store is configured, the state is saved after
+// every successful sync. Only one Run may be active at a time.
+func (ts *TickTickState) Run(ctx context.Context) error {
+	ts.runMu.Lock()
+	if ts.status.Running {
+		ts.runMu.Unlock()
+		return errors.New("state is already running")
+	}
+	ts.status.Running = true
+	ts.runMu.Unlock()
+	defer func() {
+		ts.runMu.Lock()
+		ts.status.Running = false
+		ts.runMu.Unlock()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		err := ts.Sync(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Could not sync state: %s\n", err)
+		} else if ts.store != nil {
+			if err := ts.Save(); err != nil {
+				log.Printf("Could not save state: %s\n", err)
+			}
+		}
+		timer.Reset(ts.nextSyncDelay())
+	}
+}
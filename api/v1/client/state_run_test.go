@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStateRun(t *testing.T) {
+	ts, err := NewTickTickState(newFakeStateClient(), WithLazyLoad(), WithSyncInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ts.Run(ctx)
+	}()
+
+	var first time.Time
+	waitFor(t, func() bool {
+		first = ts.SyncStatus().LastSuccess
+		return !first.IsZero()
+	})
+	waitFor(t, func() bool {
+		return ts.SyncStatus().LastSuccess.After(first)
+	})
+	if !ts.SyncStatus().Running {
+		t.Error("Expected status to report running")
+	}
+	if err := ts.Run(ctx); err == nil {
+		t.Error("Expected error starting a second run")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancellation")
+	}
+	if ts.SyncStatus().Running {
+		t.Error("Expected status to report stopped")
+	}
+	if _, ok := ts.TaskByID("t1"); !ok {
+		t.Error("Expected synced task t1 in the model")
+	}
+}
+
+func TestStateRunBackoff(t *testing.T) {
+	fc := &offlineIncrementalClient{fakeStateClient: newFakeStateClient()}
+	ts, err := NewTickTickState(fc, WithLazyLoad(), WithSyncInterval(time.Millisecond), WithSyncJitter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- ts.Run(ctx)
+	}()
+	waitFor(t, func() bool {
+		return ts.SyncStatus().ConsecutiveFailures >= 3
+	})
+	cancel()
+	<-done
+
+	status := ts.SyncStatus()
+	if status.LastError == nil || !status.LastSuccess.IsZero() {
+		t.Errorf("Expected only failures, got %+v", status)
+	}
+}
+
+func TestStateNextSyncDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{3, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		ts := &TickTickState{syncInterval: time.Minute, maxBackoff: 5 * time.Minute}
+		ts.status.ConsecutiveFailures = tt.failures
+		if got := ts.nextSyncDelay(); got != tt.want {
+			t.Errorf("Expected %s after %d failures, got %s", tt.want, tt.failures, got)
+		}
+	}
+
+	ts := &TickTickState{syncInterval: time.Minute, syncJitter: 10 * time.Second, maxBackoff: time.Hour}
+	for i := 0; i < 100; i++ {
+		if got := ts.nextSyncDelay(); got < 50*time.Second || got > 70*time.Second {
+			t.Fatalf("Expected delay within jitter, got %s", got)
+		}
+	}
+}
+
+func TestStateWithSyncInterval(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		want     time.Duration
+	}{
+		{time.Minute, time.Minute},
+		{0, DEFAULT_SYNC_INTERVAL},
+		{-time.Second, DEFAULT_SYNC_INTERVAL},
+	}
+	for _, tt := range tests {
+		ts, err := NewTickTickState(newFakeStateClient(), WithLazyLoad(), WithSyncInterval(tt.interval))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts.syncInterval != tt.want {
+			t.Errorf("Expected interval %s for %s, got %s", tt.want, tt.interval, ts.syncInterval)
+		}
+		if got := ts.nextSyncDelay(); got < tt.want*9/10 {
+			t.Errorf("Expected a delay of about %s for %s, got %s", tt.want, tt.interval, got)
+		}
+	}
+}
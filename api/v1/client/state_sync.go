@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+// IncrementalClient is implemented by clients that can return only the
+// changes made since a checkpoint, such as the v2 web client.
+type IncrementalClient interface {
+	Sync(ctx context.Context, checkPoint int64) (*v2.SyncResponse, error)
+}
+
+var _ IncrementalClient = (*v2.Client)(nil)
+
+func (ts *TickTickState) CheckPoint() int64 {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.checkPoint
+}
+
+// Sync brings the model up to date. Clients implementing IncrementalClient
+// only download the changes since the last checkpoint, falling back to a full
+// resync if the checkpoint is rejected; other clients reload everything. The
+// download does not hold the model lock, so reads and mutations go on while
+// it is in flight; syncs themselves run one at a time.
+func (ts *TickTickState) Sync(ctx context.Context) error {
+	defer ts.flushEvents()
+	ts.syncMu.Lock()
+	defer ts.syncMu.Unlock()
+	start := time.Now()
+	err := ts.sync(ctx)
+	ts.recordSync(start, err)
+	return err
+}
+
+// sync pulls the server's changes and then replays the offline queue against
+// them, so queued updates are checked for conflicts with the latest server
+// copies. Queued changes that could not be replayed are applied to the model
+// again afterwards.
+func (ts *TickTickState) sync(ctx context.Context) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	apply, err := ts.pullLatest(ctx, false)
+	if err != nil {
+		return err
+	}
+	apply()
+	if len(ts.queue) == 0 {
+		return nil
+	}
+	err = ts.replayQueue(ctx)
+	ts.reapplyQueue()
+	if err != nil && (isNetworkError(err) || ctx.Err() != nil) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Could not replay queued changes: %s\n", err)
+	}
+	return nil
+}
+
+// MAX_PULL_ATTEMPTS is how many times a sync downloads the server's changes
+// before giving up because the model kept changing during the download.
+const MAX_PULL_ATTEMPTS = 3
+
+var ErrModelChanged = errors.New("the model kept changing while syncing")
+
+// pullLatest pulls the server's changes, or everything if full is set,
+// releasing the lock during the download. If the model changed in the
+// meantime, applying the older response could overwrite newer local state, so
+// the changes are pulled again. The caller must hold syncMu and the lock,
+// which is held again when pullLatest returns.
+func (ts *TickTickState) pullLatest(ctx context.Context, full bool) (func(), error) {
+	for attempt := 0; attempt < MAX_PULL_ATTEMPTS; attempt++ {
+		loaded, checkPoint, generation := ts.loaded, ts.checkPoint, ts.generation
+		ts.mu.Unlock()
+		apply, err := ts.pull(ctx, loaded && !full, checkPoint)
+		ts.mu.Lock()
+		if err != nil {
+			return nil, err
+		}
+		if ts.loaded == loaded && ts.checkPoint == checkPoint && ts.generation == generation {
+			return apply, nil
+		}
+	}
+	return nil, ErrModelChanged
+}
+
+// pull downloads what changed since checkPoint, or everything if the model is
+// not loaded, returning a function that applies it to the model. The
+// function must be called with the lock held; pull itself does not need it.
+func (ts *TickTickState) pull(ctx context.Context, loaded bool, checkPoint int64) (func(), error) {
+	ic, ok := ts.client.(IncrementalClient)
+	if !ok {
+		projs, err := ts.client.GetAllProjects(true)
+		if err != nil {
+			return nil, err
+		}
+		inbox, err := ts.client.GetInbox()
+		if err != nil {
+			return nil, err
+		}
+		projs = append(projs, inbox)
+		return func() { ts.replaceModel(projs, nil) }, nil
+	}
+	if loaded && checkPoint != 0 {
+		sr, err := ic.Sync(ctx, checkPoint)
+		if err == nil {
+			return func() { ts.applySync(sr) }, nil
+		}
+		if !errors.Is(err, v2.ErrCheckpointRejected) {
+			return nil, err
+		}
+	}
+	sr, err := ic.Sync(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return func() { ts.applyFullSync(sr) }, nil
+}
+
+// applyFullSync replaces the model with the full account state in sr. The
+// caller must hold the lock.
+func (ts *TickTickState) applyFullSync(sr *v2.SyncResponse) {
+	projs := make([]*project.Project, 0, len(sr.ProjectProfiles)+1)
+	hasInbox := false
+	for _, p := range sr.ProjectProfiles {
+		if p.Id == sr.InboxId {
+			hasInbox = true
+		}
+		projs = append(projs, p)
+	}
+	if !hasInbox && sr.InboxId != "" {
+		projs = append(projs, &project.Project{Id: sr.InboxId, Name: "Inbox"})
+	}
+	byProject := map[string][]tasks.Task{}
+	completed := map[string]bool{}
+	for _, t := range syncedTasks(sr) {
+		if t.Status == tasks.Completed || t.Status == tasks.WontDo {
+			completed[t.Id] = true
+			continue
+		}
+		byProject[t.ProjectId] = append(byProject[t.ProjectId], t)
+	}
+	for _, p := range projs {
+		p.Tasks = byProject[p.Id]
+	}
+	ts.replaceModel(projs, completed)
+	ts.filters = sr.Filters
+	ts.tags = sr.Tags
+	ts.checkPoint = sr.CheckPoint
+}
+
+func syncedTasks(sr *v2.SyncResponse) []tasks.Task {
+	tb := sr.SyncTaskBean
+	ts := make([]tasks.Task, 0, len(tb.Add)+len(tb.Update)+len(tb.TagUpdate))
+	ts = append(ts, tb.Add...)
+	ts = append(ts, tb.Update...)
+	return append(ts, tb.TagUpdate...)
+}
+
+// applySync applies the deltas of an incremental sync to the model. The
+// caller must hold the lock.
+func (ts *TickTickState) applySync(sr *v2.SyncResponse) {
+	// Incremental responses carry no project deletions, and a project missing
+	// from them may just be unchanged, so projects are only removed by a full
+	// sync.
+	for _, p := range sr.ProjectProfiles {
+		ts.putProject(*p)
+	}
+	for _, t := range syncedTasks(sr) {
+		if t.Status == tasks.Completed || t.Status == tasks.WontDo {
+			ts.dropTask(t, TaskCompleted)
+			continue
+		}
+		ts.putTask(t)
+	}
+	for _, ref := range sr.SyncTaskBean.Delete {
+		ts.dropTask(tasks.Task{Id: ref.TaskId, ProjectId: ref.ProjectId}, TaskDeleted)
+	}
+	if sr.Filters != nil {
+		ts.filters = sr.Filters
+	}
+	if sr.Tags != nil {
+		ts.tags = sr.Tags
+	}
+	ts.checkPoint = sr.CheckPoint
+}
@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+type fakeIncrementalClient struct {
+	*fakeStateClient
+	responses   []*v2.SyncResponse
+	checkPoints []int64
+	reject      bool
+}
+
+func (f *fakeIncrementalClient) Sync(ctx context.Context, checkPoint int64) (*v2.SyncResponse, error) {
+	f.checkPoints = append(f.checkPoints, checkPoint)
+	if checkPoint != 0 && f.reject {
+		return nil, v2.ErrCheckpointRejected
+	}
+	sr := f.responses[0]
+	f.responses = f.responses[1:]
+	return sr, nil
+}
+
+func fullSyncResponse() *v2.SyncResponse {
+	return &v2.SyncResponse{
+		CheckPoint:      100,
+		InboxId:         "inbox123456",
+		ProjectProfiles: []*project.Project{{Id: "p1", Name: "Work"}},
+		SyncTaskBean: v2.SyncTaskBean{
+			Update: []tasks.Task{
+				{Id: "t1", ProjectId: "p1", Title: "Write report"},
+				{Id: "t2", ProjectId: "inbox123456", Title: "Buy milk"},
+			},
+		},
+	}
+}
+
+func TestStateIncrementalSync(t *testing.T) {
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses: []*v2.SyncResponse{
+			fullSyncResponse(),
+			{
+				CheckPoint: 200,
+				SyncTaskBean: v2.SyncTaskBean{
+					Add:    []tasks.Task{{Id: "t3", ProjectId: "p1", Title: "Review"}},
+					Update: []tasks.Task{{Id: "t1", ProjectId: "p1", Title: "Write report", Status: tasks.Completed}},
+					Delete: []v2.TaskRef{{TaskId: "t2", ProjectId: "inbox123456"}},
+					TagUpdate: []tasks.Task{
+						{Id: "t4", ProjectId: "inbox123456", Title: "Pay rent", Tags: []string{"bills"}},
+					},
+				},
+			},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.CheckPoint() != 100 || len(ts.Projects) != 2 {
+		t.Fatalf("Expected checkpoint 100 and 2 projects, got %d and %d", ts.CheckPoint(), len(ts.Projects))
+	}
+
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fc.checkPoints[1] != 100 {
+		t.Fatalf("Expected second sync from checkpoint 100, got %d", fc.checkPoints[1])
+	}
+	if ts.CheckPoint() != 200 {
+		t.Fatalf("Expected checkpoint 200, got %d", ts.CheckPoint())
+	}
+	if _, ok := ts.TaskByID("t1"); ok {
+		t.Fatal("Expected completed task to be removed")
+	}
+	if _, ok := ts.TaskByID("t2"); ok {
+		t.Fatal("Expected deleted task to be removed")
+	}
+	if got := ts.TasksInProject("p1"); len(got) != 1 || got[0].Id != "t3" {
+		t.Fatalf("Expected only t3 in p1, got %v", got)
+	}
+	if got := ts.TasksWithTag("bills"); len(got) != 1 || got[0].Id != "t4" {
+		t.Fatalf("Expected the tag update to be applied, got %v", got)
+	}
+}
+
+func TestStateSyncRejectedCheckpoint(t *testing.T) {
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses:       []*v2.SyncResponse{fullSyncResponse(), fullSyncResponse()},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.reject = true
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []int64{0, 100, 0}
+	if len(fc.checkPoints) != len(want) {
+		t.Fatalf("Expected sync checkpoints %v, got %v", want, fc.checkPoints)
+	}
+	for i := range want {
+		if fc.checkPoints[i] != want[i] {
+			t.Fatalf("Expected sync checkpoints %v, got %v", want, fc.checkPoints)
+		}
+	}
+	if _, ok := ts.TaskByID("t2"); !ok {
+		t.Fatal("Expected full resync to restore the model")
+	}
+}
+
+func TestStateFullResyncEvents(t *testing.T) {
+	resync := fullSyncResponse()
+	resync.SyncTaskBean.Update = []tasks.Task{{Id: "t1", ProjectId: "p1", Title: "Write report", Status: tasks.Completed}}
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses:       []*v2.SyncResponse{fullSyncResponse(), resync},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := ts.Subscribe()
+	defer sub.Close()
+
+	fc.reject = true
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := map[string]EventType{}
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		events[e.Task.Id] = e.Type
+	}
+	if events["t1"] != TaskCompleted || events["t2"] != TaskDeleted {
+		t.Fatalf("Expected t1 completed and t2 deleted, got %v", events)
+	}
+}
+
+func TestStateSyncKeepsUnlistedProjects(t *testing.T) {
+	fc := &fakeIncrementalClient{
+		fakeStateClient: newFakeStateClient(),
+		responses: []*v2.SyncResponse{
+			fullSyncResponse(),
+			{
+				CheckPoint:      200,
+				ProjectProfiles: []*project.Project{{Id: "p2", Name: "Home"}},
+				SyncTaskBean: v2.SyncTaskBean{
+					Update: []tasks.Task{{Id: "t9", ProjectId: "p2", Title: "Unseen", Status: tasks.Completed}},
+				},
+			},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := ts.Subscribe()
+	defer sub.Close()
+
+	if err := ts.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range ts.Projects {
+		ids = append(ids, p.Id)
+	}
+	if !reflect.DeepEqual(ids, []string{"p1", "inbox123456", "p2"}) {
+		t.Fatalf("Expected p1 to be kept and p2 added, got %v", ids)
+	}
+	if _, ok := ts.TaskByID("t1"); !ok {
+		t.Fatal("Expected the tasks of the unlisted project to be kept")
+	}
+	if _, ok := ts.TaskByID("t2"); !ok {
+		t.Fatal("Expected the inbox to be kept")
+	}
+	var events []string
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		events = append(events, e.Type.String()+" "+e.Task.Id+e.Project.Id)
+	}
+	want := []string{ProjectChanged.String() + " p2"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("Expected events %v without one for the unseen task, got %v", want, events)
+	}
+}
+
+// blockingSyncClient blocks incremental syncs, and full ones if full is set,
+// until release is closed.
+type blockingSyncClient struct {
+	*fakeIncrementalClient
+	full    bool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingSyncClient) Sync(ctx context.Context, checkPoint int64) (*v2.SyncResponse, error) {
+	if (checkPoint != 0 || c.full) && c.started != nil {
+		close(c.started)
+		c.started = nil
+		<-c.release
+	}
+	return c.fakeIncrementalClient.Sync(ctx, checkPoint)
+}
+
+func TestStateSyncDoesNotBlockReads(t *testing.T) {
+	bc := &blockingSyncClient{
+		fakeIncrementalClient: &fakeIncrementalClient{
+			fakeStateClient: newFakeStateClient(),
+			responses: []*v2.SyncResponse{
+				fullSyncResponse(),
+				{CheckPoint: 200, SyncTaskBean: v2.SyncTaskBean{Update: []tasks.Task{{Id: "t1", ProjectId: "p1", Title: "Stale"}}}},
+				{CheckPoint: 300, SyncTaskBean: v2.SyncTaskBean{Update: []tasks.Task{{Id: "t3", ProjectId: "p1", Title: "Review"}}}},
+			},
+		},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	ts, err := NewTickTickState(bc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := bc.started
+	done := make(chan error)
+	go func() {
+		done <- ts.Sync(context.Background())
+	}()
+	<-started
+
+	if _, ok := ts.TaskByID("t1"); !ok {
+		t.Fatal("Expected reads to go on during a sync")
+	}
+	t1 := tasks.Task{Id: "t1", ProjectId: "p1", Title: "Write the report"}
+	if err := ts.UpdateTask(&t1); err != nil {
+		t.Fatal(err)
+	}
+	close(bc.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int64{0, 100, 100}; !reflect.DeepEqual(bc.checkPoints, want) {
+		t.Errorf("Expected the changes pulled again after the update, got checkpoints %v", bc.checkPoints)
+	}
+	if got, _ := ts.TaskByID("t1"); got.Title != "Write the report" {
+		t.Errorf("Expected the local update to survive the older response, got %q", got.Title)
+	}
+	if _, ok := ts.TaskByID("t3"); !ok || ts.CheckPoint() != 300 {
+		t.Errorf("Expected the second response to be applied, at checkpoint %d", ts.CheckPoint())
+	}
+}
+
+func TestStateFullLoadDoesNotBlockReads(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []StateOption
+		load func(ts *TickTickState) error
+	}{
+		{"GetAll", nil, func(ts *TickTickState) error { return ts.GetAll() }},
+		{
+			"Lazy load", []StateOption{WithLazyLoad()}, func(ts *TickTickState) error {
+				_, err := ts.GetProjects()
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				bc := &blockingSyncClient{
+					fakeIncrementalClient: &fakeIncrementalClient{
+						fakeStateClient: newFakeStateClient(),
+						responses:       []*v2.SyncResponse{fullSyncResponse(), fullSyncResponse()},
+					},
+				}
+				ts, err := NewTickTickState(bc, tc.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				bc.full = true
+				bc.started, bc.release = make(chan struct{}), make(chan struct{})
+				started := bc.started
+				done := make(chan error)
+				go func() {
+					done <- tc.load(ts)
+				}()
+				<-started
+
+				read := make(chan int64)
+				go func() {
+					read <- ts.CheckPoint()
+				}()
+				select {
+				case <-read:
+				case <-time.After(time.Second):
+					t.Fatal("Expected reads to go on during a full load")
+				}
+				close(bc.release)
+				if err := <-done; err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := ts.TaskByID("t2"); !ok || ts.CheckPoint() != 100 {
+					t.Errorf("Expected the full load to be applied, at checkpoint %d", ts.CheckPoint())
+				}
+			},
+		)
+	}
+}
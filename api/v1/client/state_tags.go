@@ -0,0 +1,204 @@
+package client
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+// TagClient is implemented by clients that can manage tags account-wide,
+// such as the v2 web client.
+type TagClient interface {
+	ListTags() ([]*tag.Tag, error)
+	CreateTag(t *tag.Tag) error
+	UpdateTag(t *tag.Tag) error
+	RenameTag(oldName, newLabel string) error
+	MergeTags(from, into string) error
+	DeleteTag(name string) error
+}
+
+var _ TagClient = (*v2.Client)(nil)
+
+var ErrTagsUnsupported = errors.New("client does not support managing tags")
+
+func (ts *TickTickState) tagClient() (TagClient, error) {
+	tc, ok := ts.client.(TagClient)
+	if !ok {
+		return nil, ErrTagsUnsupported
+	}
+	return tc, nil
+}
+
+// Tags returns the tags of the account ordered by sort order. Tags only known
+// from tasks, as with clients that cannot list tags, are included with their
+// name as label.
+func (ts *TickTickState) Tags() ([]tag.Tag, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err := ts.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	res := make([]tag.Tag, 0, len(ts.tags))
+	known := map[string]bool{}
+	for _, t := range ts.tags {
+		res = append(res, *t)
+		known[t.Name] = true
+	}
+	var extra []tag.Tag
+	for name := range ts.index.byTag {
+		if !known[name] {
+			extra = append(extra, tag.Tag{Name: name, Label: name})
+		}
+	}
+	sort.SliceStable(
+		res, func(i, j int) bool {
+			return res[i].SortOrder < res[j].SortOrder
+		},
+	)
+	sort.Slice(
+		extra, func(i, j int) bool {
+			return extra[i].Name < extra[j].Name
+		},
+	)
+	return append(res, extra...), nil
+}
+
+func (ts *TickTickState) CreateTag(t *tag.Tag) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tc, err := ts.tagClient()
+	if err != nil {
+		return err
+	}
+	if err := tc.CreateTag(t); err != nil {
+		return err
+	}
+	ts.putTag(*t)
+	return nil
+}
+
+// UpdateTag saves a tag's color, parent or sort order.
+func (ts *TickTickState) UpdateTag(t *tag.Tag) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tc, err := ts.tagClient()
+	if err != nil {
+		return err
+	}
+	if err := tc.UpdateTag(t); err != nil {
+		return err
+	}
+	ts.putTag(*t)
+	return nil
+}
+
+// RenameTag renames a tag and, like the server, every task's use of it.
+func (ts *TickTickState) RenameTag(oldName, newLabel string) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tc, err := ts.tagClient()
+	if err != nil {
+		return err
+	}
+	if err := tc.RenameTag(oldName, newLabel); err != nil {
+		return err
+	}
+	oldName, newName := tag.NameOf(oldName), tag.NameOf(newLabel)
+	for _, t := range ts.tags {
+		if t.Name == oldName {
+			t.Name, t.RawName, t.Label = newName, newName, newLabel
+		}
+		if t.Parent == oldName {
+			t.Parent = newName
+		}
+	}
+	ts.retag(oldName, newName)
+	return nil
+}
+
+// MergeTags retags every task tagged from with into and deletes from.
+func (ts *TickTickState) MergeTags(from, into string) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tc, err := ts.tagClient()
+	if err != nil {
+		return err
+	}
+	if err := tc.MergeTags(from, into); err != nil {
+		return err
+	}
+	ts.dropTag(from)
+	ts.retag(from, tag.NameOf(into))
+	return nil
+}
+
+// DeleteTag deletes the tag and removes it from every task.
+func (ts *TickTickState) DeleteTag(name string) error {
+	defer ts.flushEvents()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tc, err := ts.tagClient()
+	if err != nil {
+		return err
+	}
+	if err := tc.DeleteTag(name); err != nil {
+		return err
+	}
+	ts.dropTag(name)
+	ts.retag(name, "")
+	return nil
+}
+
+// putTag stores a copy of t, replacing the tag with the same name. The caller
+// must hold the lock.
+func (ts *TickTickState) putTag(t tag.Tag) {
+	ts.generation++
+	for i, existing := range ts.tags {
+		if existing.Name == t.Name {
+			ts.tags[i] = &t
+			return
+		}
+	}
+	ts.tags = append(ts.tags, &t)
+}
+
+func (ts *TickTickState) dropTag(name string) {
+	ts.generation++
+	name = tag.NameOf(name)
+	for i, t := range ts.tags {
+		if t.Name == name {
+			ts.tags = append(ts.tags[:i:i], ts.tags[i+1:]...)
+			return
+		}
+	}
+}
+
+// retag replaces the tag from with to on every task in the model, or removes
+// it if to is empty. The server gives the tasks new etags that are only known
+// after the next sync, so theirs are cleared to keep the conflict check from
+// flagging the next update. The caller must hold the lock.
+func (ts *TickTickState) retag(from, to string) {
+	from = normalizeTag(from)
+	for _, t := range ts.index.collect(ts.index.byTag[from]) {
+		var retagged []string
+		seen := map[string]bool{}
+		for _, name := range t.Tags {
+			if normalizeTag(name) == from {
+				name = to
+			}
+			if name == "" || seen[normalizeTag(name)] {
+				continue
+			}
+			seen[normalizeTag(name)] = true
+			retagged = append(retagged, name)
+		}
+		t.Tags = retagged
+		t.Etag, t.ModifiedTime = "", time.Time{}
+		ts.putTask(t)
+	}
+}
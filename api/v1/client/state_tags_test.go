@@ -0,0 +1,170 @@
+package client
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+type fakeTagClient struct {
+	*fakeIncrementalClient
+	calls []string
+}
+
+func (f *fakeTagClient) ListTags() ([]*tag.Tag, error) {
+	return nil, nil
+}
+
+func (f *fakeTagClient) CreateTag(t *tag.Tag) error {
+	f.calls = append(f.calls, "create "+t.Label)
+	t.Name = tag.NameOf(t.Label)
+	return nil
+}
+
+func (f *fakeTagClient) UpdateTag(t *tag.Tag) error {
+	f.calls = append(f.calls, "update "+t.Name)
+	return nil
+}
+
+func (f *fakeTagClient) RenameTag(oldName, newLabel string) error {
+	f.calls = append(f.calls, "rename "+oldName+" "+newLabel)
+	return nil
+}
+
+func (f *fakeTagClient) MergeTags(from, into string) error {
+	f.calls = append(f.calls, "merge "+from+" "+into)
+	return nil
+}
+
+func (f *fakeTagClient) DeleteTag(name string) error {
+	f.calls = append(f.calls, "delete "+name)
+	return nil
+}
+
+func tagNames(ts []tag.Tag) []string {
+	var names []string
+	for _, t := range ts {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func TestStateTags(t *testing.T) {
+	full := fullSyncResponse()
+	full.Tags = []*tag.Tag{
+		{Name: "work", Label: "Work", SortOrder: 2},
+		{Name: "home", Label: "Home", SortOrder: 1},
+	}
+	full.SyncTaskBean.Update = []tasks.Task{
+		{Id: "t1", ProjectId: "p1", Title: "Write report", Tags: []string{"work", "urgent"}},
+		{Id: "t2", ProjectId: "inbox123456", Title: "Buy milk", Tags: []string{"home", "Work"}},
+	}
+	fc := &fakeTagClient{
+		fakeIncrementalClient: &fakeIncrementalClient{
+			fakeStateClient: newFakeStateClient(),
+			responses:       []*v2.SyncResponse{full},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ts.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := tagNames(got); !reflect.DeepEqual(names, []string{"home", "work", "urgent"}) {
+		t.Fatalf("Expected account tags by sort order then task tags, got %v", names)
+	}
+
+	if err := ts.CreateTag(&tag.Tag{Label: "Errands", SortOrder: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.RenameTag("work", "Job"); err != nil {
+		t.Fatal(err)
+	}
+	t1, _ := ts.TaskByID("t1")
+	if !reflect.DeepEqual(t1.Tags, []string{"job", "urgent"}) {
+		t.Errorf("Expected t1 retagged, got %v", t1.Tags)
+	}
+	if len(ts.TasksWithTag("work")) != 0 || len(ts.TasksWithTag("job")) != 2 {
+		t.Errorf("Expected the tag index to follow the rename")
+	}
+
+	if err := ts.MergeTags("home", "job"); err != nil {
+		t.Fatal(err)
+	}
+	t2, _ := ts.TaskByID("t2")
+	if !reflect.DeepEqual(t2.Tags, []string{"job"}) {
+		t.Errorf("Expected t2 merged without duplicates, got %v", t2.Tags)
+	}
+
+	if err := ts.DeleteTag("urgent"); err != nil {
+		t.Fatal(err)
+	}
+	t1, _ = ts.TaskByID("t1")
+	if !reflect.DeepEqual(t1.Tags, []string{"job"}) {
+		t.Errorf("Expected urgent removed from t1, got %v", t1.Tags)
+	}
+
+	got, _ = ts.Tags()
+	if names := tagNames(got); !reflect.DeepEqual(names, []string{"job", "errands"}) {
+		t.Errorf("Expected job and errands, got %v", names)
+	}
+	want := []string{"create Errands", "rename work Job", "merge home job", "delete urgent"}
+	if !reflect.DeepEqual(fc.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, fc.calls)
+	}
+
+	plain, err := NewTickTickState(newFakeStateClient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.DeleteTag("work"); !errors.Is(err, ErrTagsUnsupported) {
+		t.Errorf("Expected ErrTagsUnsupported, got %v", err)
+	}
+}
+
+func TestStateRenameTag(t *testing.T) {
+	full := fullSyncResponse()
+	full.Tags = []*tag.Tag{
+		{Name: "work", Label: "Work"},
+		{Name: "reports", Label: "Reports", Parent: "work"},
+	}
+	modified := time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC)
+	full.SyncTaskBean.Update = []tasks.Task{
+		{Id: "t1", ProjectId: "p1", Title: "Write report", Tags: []string{"work"}, Etag: "a", ModifiedTime: modified},
+		{Id: "t2", ProjectId: "p1", Title: "Buy milk", Etag: "b", ModifiedTime: modified},
+	}
+	fc := &fakeTagClient{
+		fakeIncrementalClient: &fakeIncrementalClient{
+			fakeStateClient: newFakeStateClient(),
+			responses:       []*v2.SyncResponse{full},
+		},
+	}
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.RenameTag("Work", "Job"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := ts.Tags()
+	if len(got) != 2 || got[0].Name != "job" || got[1].Parent != "job" {
+		t.Errorf("Expected the child tag to follow the renamed parent, got %+v", got)
+	}
+	t1, _ := ts.TaskByID("t1")
+	if t1.Etag != "" || !t1.ModifiedTime.IsZero() {
+		t.Errorf("Expected the retagged task's stale etag to be cleared, got %q %s", t1.Etag, t1.ModifiedTime)
+	}
+	t2, _ := ts.TaskByID("t2")
+	if t2.Etag != "b" {
+		t.Errorf("Expected untouched tasks to keep their etag, got %q", t2.Etag)
+	}
+}
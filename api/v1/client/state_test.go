@@ -1,20 +1,182 @@
 package client
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
 	"github.com/joho/godotenv"
 )
 
+type fakeStateClient struct {
+	projects []*project.Project
+	inbox    *project.Project
+	fetches  int
+	created  int
+	err      error
+}
+
+func (f *fakeStateClient) GetAllProjects(includeTasks bool) ([]*project.Project, error) {
+	f.fetches++
+	return f.projects, nil
+}
+
+func (f *fakeStateClient) GetInbox() (*project.Project, error) {
+	return f.inbox, nil
+}
+
+func (f *fakeStateClient) allProjects() []*project.Project {
+	return append(append([]*project.Project(nil), f.projects...), f.inbox)
+}
+
+func (f *fakeStateClient) GetProjectById(id string, includeTasks bool) (*project.Project, error) {
+	for _, p := range f.allProjects() {
+		if p.Id == id {
+			return p, nil
+		}
+	}
+	return nil, errors.New("project not found")
+}
+
+func (f *fakeStateClient) GetTask(task *tasks.Task) error {
+	for _, p := range f.allProjects() {
+		for _, t := range p.Tasks {
+			if t.Id == task.Id {
+				*task = t.Clone()
+				return f.err
+			}
+		}
+	}
+	return errors.New("task not found")
+}
+
+func (f *fakeStateClient) CreateTask(task *tasks.Task) error {
+	f.created++
+	task.Id = fmt.Sprintf("created%d", f.created)
+	if task.ProjectId == "" {
+		task.ProjectId = f.inbox.Id
+	}
+	return f.err
+}
+
+func (f *fakeStateClient) UpdateTask(task *tasks.Task) error {
+	return f.err
+}
+
+func (f *fakeStateClient) CompleteTask(task *tasks.Task) error {
+	if f.err != nil {
+		return f.err
+	}
+	task.Status = tasks.Completed
+	return nil
+}
+
+func (f *fakeStateClient) DeleteTask(task *tasks.Task) error {
+	return f.err
+}
+
+func (f *fakeStateClient) MoveTask(task *tasks.Task, toProjectID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	task.ProjectId = toProjectID
+	return nil
+}
+
+func (f *fakeStateClient) CreateNewProject(proj *project.Project) error {
+	f.created++
+	proj.Id = fmt.Sprintf("created%d", f.created)
+	return f.err
+}
+
+func (f *fakeStateClient) UpdateProject(proj *project.Project) error {
+	return f.err
+}
+
+func (f *fakeStateClient) DeleteProjectById(id string) error {
+	return f.err
+}
+
+func newFakeStateClient() *fakeStateClient {
+	return &fakeStateClient{
+		projects: []*project.Project{
+			{
+				Id:   "p1",
+				Name: "Work",
+				Tasks: []tasks.Task{
+					{Id: "t1", ProjectId: "p1", Title: "Write report"},
+				},
+			},
+		},
+		inbox: &project.Project{
+			Id:   "inbox123456",
+			Name: "Inbox",
+			Tasks: []tasks.Task{
+				{Id: "t2", ProjectId: "inbox123456", Title: "Buy milk"},
+			},
+		},
+	}
+}
+
 func TestNewTickTickState(t *testing.T) {
 	err := godotenv.Load("../../../.env")
 	if err != nil {
 		t.Log("Could not load .env file")
 	}
-	ts, err := NewTickTickState()
+	ts, err := NewTickTickStateFromEnv()
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	ts.WriteToJSON()
 }
+
+func TestNewTickTickStateLoading(t *testing.T) {
+	fc := newFakeStateClient()
+	ts, err := NewTickTickState(fc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.fetches != 1 {
+		t.Fatalf("Expected eager state to fetch once, got %d", fc.fetches)
+	}
+	if len(ts.Projects) != 2 {
+		t.Fatalf("Expected 2 projects including the inbox, got %d", len(ts.Projects))
+	}
+
+	fc = newFakeStateClient()
+	ts, err = NewTickTickState(fc, WithLazyLoad())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.fetches != 0 {
+		t.Fatalf("Expected lazy state not to fetch on creation, got %d", fc.fetches)
+	}
+	projs, err := ts.GetProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.fetches != 1 || len(projs) != 2 {
+		t.Fatalf("Expected 1 fetch and 2 projects, got %d fetches and %d projects", fc.fetches, len(projs))
+	}
+
+	for _, p := range projs {
+		p.Name = "Changed"
+		for i := range p.Tasks {
+			p.Tasks[i].Title = "Changed"
+		}
+	}
+	again, _ := ts.GetProjects()
+	for _, p := range again {
+		if p.Name == "Changed" {
+			t.Errorf("Expected GetProjects to return copies, got project %+v", p)
+		}
+		for _, task := range p.Tasks {
+			if task.Title == "Changed" {
+				t.Errorf("Expected GetProjects to return copies, got task %+v", task)
+			}
+		}
+	}
+}
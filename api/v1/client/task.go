@@ -11,8 +11,11 @@ import (
 
 	"github.com/herzs11/go-ticktick/api/v1/types/project"
 	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
 )
 
+var ErrTaskNotFound = errors.New("task not found")
+
 func (c *TickTickClient) getTaskByProjectIdAndTaskID(projectID, taskID string) (*tasks.Task, error) {
 	req, err := http.NewRequest(
 		"GET", fmt.Sprintf("%s%s/%s/task/%s", API_BASE_URL, project.PROJECT_ENDPOINT, projectID, taskID), nil,
@@ -26,6 +29,9 @@ func (c *TickTickClient) getTaskByProjectIdAndTaskID(projectID, taskID string) (
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s in project '%s'", ErrTaskNotFound, taskID, projectID)
+	}
 
 	var t tasks.Task
 	err = json.NewDecoder(resp.Body).Decode(&t)
@@ -33,18 +39,42 @@ func (c *TickTickClient) getTaskByProjectIdAndTaskID(projectID, taskID string) (
 		return nil, err
 	}
 	if t.Id == "" {
-		return nil, errors.New(fmt.Sprintf("Task with id %s not found with project '%s'", taskID, projectID))
+		return nil, fmt.Errorf("%w: %s in project '%s'", ErrTaskNotFound, taskID, projectID)
 	}
 	return &t, nil
 
 }
 
+// GetTaskById finds a task without knowing its project. The open API can only
+// fetch tasks by project, so after the inbox it tries every project in turn,
+// costing a request per project; prefer GetTask with a ProjectId. Only
+// ErrTaskNotFound moves it on to the next project; other errors are returned.
 func (c *TickTickClient) GetTaskById(taskID string) (*tasks.Task, error) {
-	return c.getTaskByProjectIdAndTaskID("inbox", taskID)
+	inboxID, err := c.resolveProjectId("inbox")
+	if errors.Is(err, ErrInboxUnresolved) {
+		inboxID = "inbox"
+	} else if err != nil {
+		return nil, err
+	}
+	t, err := c.getTaskByProjectIdAndTaskID(inboxID, taskID)
+	if !errors.Is(err, ErrTaskNotFound) {
+		return t, err
+	}
+	projs, err := c.GetAllProjects(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projs {
+		t, err := c.getTaskByProjectIdAndTaskID(p.Id, taskID)
+		if !errors.Is(err, ErrTaskNotFound) {
+			return t, err
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in any project", ErrTaskNotFound, taskID)
 }
 
 func (c *TickTickClient) GetTask(task *tasks.Task) error {
-	if task.ProjectId == "" || strings.HasPrefix(task.ProjectId, "inbox") {
+	if task.ProjectId == "" {
 		t, err := c.GetTaskById(task.Id)
 		if err != nil {
 			return err
@@ -52,7 +82,11 @@ func (c *TickTickClient) GetTask(task *tasks.Task) error {
 		*task = *t
 		return nil
 	}
-	t, err := c.getTaskByProjectIdAndTaskID(task.ProjectId, task.Id)
+	projectID, err := c.resolveProjectId(task.ProjectId)
+	if err != nil {
+		return err
+	}
+	t, err := c.getTaskByProjectIdAndTaskID(projectID, task.Id)
 	if err != nil {
 		return err
 	}
@@ -60,7 +94,68 @@ func (c *TickTickClient) GetTask(task *tasks.Task) error {
 	return nil
 }
 
+func (c *TickTickClient) MoveTask(task *tasks.Task, toProjectID string) error {
+	return c.MoveTasks([]*tasks.Task{task}, toProjectID)
+}
+
+// ErrMoveUnsupported is returned when moving tasks without a web client, as
+// the open API cannot change a task's project.
+var ErrMoveUnsupported = errors.New("moving tasks between projects requires a web client")
+
+// MoveTasks moves every task in ts to the project with id toProjectID and
+// updates each task's ProjectId once the move succeeded. It needs a web client
+// attached with SetWebClient.
+func (c *TickTickClient) MoveTasks(ts []*tasks.Task, toProjectID string) error {
+	if toProjectID == "" {
+		return errors.New("must provide a project id to move tasks to")
+	}
+	for _, t := range ts {
+		if t.Id == "" {
+			return errors.New("task with an empty id cannot be moved")
+		}
+	}
+	if c.webClient == nil {
+		return ErrMoveUnsupported
+	}
+	toProjectID, err := c.resolveProjectId(toProjectID)
+	if err != nil {
+		return err
+	}
+	var toMove []*tasks.Task
+	var moves []v2.TaskMove
+	for _, t := range ts {
+		fromProjectID, err := c.resolveProjectId(t.ProjectId)
+		if err != nil {
+			return err
+		}
+		if fromProjectID == toProjectID {
+			continue
+		}
+		toMove = append(toMove, t)
+		moves = append(
+			moves, v2.TaskMove{
+				FromProjectId: fromProjectID,
+				TaskId:        t.Id,
+				ToProjectId:   toProjectID,
+			},
+		)
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+	if err := c.webClient.MoveTasks(moves); err != nil {
+		return err
+	}
+	for _, t := range toMove {
+		t.ProjectId = toProjectID
+	}
+	return nil
+}
+
 func (c *TickTickClient) CompleteTask(task *tasks.Task) error {
+	if err := c.resolveTaskProject(task); err != nil {
+		return err
+	}
 	req, err := http.NewRequest(
 		"POST",
 		fmt.Sprintf("%s%s/%s/task/%s/complete", API_BASE_URL, project.PROJECT_ENDPOINT, task.ProjectId, task.Id), nil,
@@ -83,6 +178,9 @@ func (c *TickTickClient) CompleteTask(task *tasks.Task) error {
 }
 
 func (c *TickTickClient) DeleteTask(task *tasks.Task) error {
+	if err := c.resolveTaskProject(task); err != nil {
+		return err
+	}
 	req, err := http.NewRequest(
 		"DELETE",
 		fmt.Sprintf("%s%s/%s/task/%s", API_BASE_URL, project.PROJECT_ENDPOINT, task.ProjectId, task.Id), nil,
@@ -107,6 +205,9 @@ func (c *TickTickClient) CreateTask(task *tasks.Task) error {
 	if err != nil {
 		return err
 	}
+	if err := c.resolveTaskProject(task); err != nil {
+		return err
+	}
 
 	data, err := json.Marshal(task)
 	if err != nil {
@@ -122,8 +223,23 @@ func (c *TickTickClient) CreateTask(task *tasks.Task) error {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("Task creation returned a non-200 status code: %d", resp.StatusCode))
+	}
 
-	return json.NewDecoder(resp.Body).Decode(task)
+	var created tasks.Task
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	if err != nil {
+		return err
+	}
+	if created.Id == "" {
+		return errors.New("Task creation returned a task without an id")
+	}
+	*task = created
+	if strings.HasPrefix(task.ProjectId, "inbox") && task.ProjectId != "inbox" {
+		c.rememberInboxId(task.ProjectId)
+	}
+	return nil
 }
 
 func (c *TickTickClient) UpdateTask(task *tasks.Task) error {
@@ -131,6 +247,9 @@ func (c *TickTickClient) UpdateTask(task *tasks.Task) error {
 	if err != nil {
 		return err
 	}
+	if err := c.resolveTaskProject(task); err != nil {
+		return err
+	}
 
 	data, err := json.Marshal(task)
 	if err != nil {
@@ -150,9 +269,7 @@ func (c *TickTickClient) UpdateTask(task *tasks.Task) error {
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		var respRaw interface{}
-		err = json.NewDecoder(resp.Body).Decode(&respRaw)
-		return err
+		return errors.New(fmt.Sprintf("Task update returned a non-200 status code: %d", resp.StatusCode))
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(task)
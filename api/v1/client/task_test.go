@@ -0,0 +1,171 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	v2 "github.com/herzs11/go-ticktick/api/v2/client"
+)
+
+// rewriteTransport sends every request to the test server instead of the API.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestHTTPClient(t *testing.T, handler http.Handler) *http.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: rewriteTransport{target}}
+}
+
+// newTestClient returns a client sending its requests to handler, with a web
+// client doing the same if web is set.
+func newTestClient(t *testing.T, handler http.Handler, web bool) *TickTickClient {
+	hc := newTestHTTPClient(t, handler)
+	c := NewTickTickClient("id", "secret", "http://localhost:8080")
+	c.SetHTTPClient(hc)
+	if web {
+		c.SetWebClient(v2.NewClient("user@example.com", "secret", v2.WithHTTPClient(hc)))
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestClientMoveTasks(t *testing.T) {
+	var moves []v2.TaskMove
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			switch r.URL.Path {
+			case "/api/v2/user/signon":
+				writeJSON(w, http.StatusOK, map[string]string{"token": "tok1", "inboxId": "inbox1"})
+			case "/api/v2/batch/taskProject":
+				var batch []v2.TaskMove
+				json.NewDecoder(r.Body).Decode(&batch)
+				moves = append(moves, batch...)
+				writeJSON(w, http.StatusOK, map[string]interface{}{})
+			default:
+				t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		},
+	)
+
+	t.Run(
+		"Web client", func(t *testing.T) {
+			c := newTestClient(t, mux, true)
+			t1 := &tasks.Task{Id: "t1", ProjectId: "p1"}
+			t2 := &tasks.Task{Id: "t2", ProjectId: "p2"}
+			if err := c.MoveTasks([]*tasks.Task{t1, t2}, "p2"); err != nil {
+				t.Fatal(err)
+			}
+			if len(moves) != 1 || moves[0] != (v2.TaskMove{FromProjectId: "p1", TaskId: "t1", ToProjectId: "p2"}) {
+				t.Fatalf("Expected only t1 to be moved, got %+v", moves)
+			}
+			if t1.ProjectId != "p2" || t2.ProjectId != "p2" {
+				t.Errorf("Expected both tasks in p2, got %s and %s", t1.ProjectId, t2.ProjectId)
+			}
+		},
+	)
+
+	testCases := []struct {
+		name    string
+		task    tasks.Task
+		to      string
+		wantErr error
+	}{
+		{"Same project", tasks.Task{Id: "t1", ProjectId: "p1"}, "p1", ErrMoveUnsupported},
+		{"Without a web client", tasks.Task{Id: "t1", ProjectId: "p1"}, "p2", ErrMoveUnsupported},
+		{"Inbox without a web client", tasks.Task{Id: "t1"}, "inbox", ErrMoveUnsupported},
+		{"Empty id", tasks.Task{ProjectId: "p1"}, "p2", errors.New("task with an empty id cannot be moved")},
+		{"Empty destination", tasks.Task{Id: "t1", ProjectId: "p1"}, "", errors.New("must provide a project id to move tasks to")},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				requests = 0
+				c := newTestClient(t, mux, false)
+				task := tc.task
+				err := c.MoveTask(&task, tc.to)
+				if tc.wantErr == nil && err != nil || tc.wantErr != nil && (err == nil || err.Error() != tc.wantErr.Error()) {
+					t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+				}
+				if task.ProjectId != tc.task.ProjectId {
+					t.Errorf("Expected the task to stay in %s, got %s", tc.task.ProjectId, task.ProjectId)
+				}
+				if requests != 0 {
+					t.Errorf("Expected no requests, got %d", requests)
+				}
+			},
+		)
+	}
+}
+
+func TestClientGetTaskById(t *testing.T) {
+	var paths []string
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/open/v1/project", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, []map[string]string{{"id": "p1"}, {"id": "p2"}})
+		},
+	)
+	mux.HandleFunc(
+		"/open/v1/project/", func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			if r.URL.Path == "/open/v1/project/p2/task/t1" {
+				writeJSON(w, http.StatusOK, map[string]string{"id": "t1", "projectId": "p2", "title": "Write report"})
+				return
+			}
+			if r.URL.Path == "/open/v1/project/p1/task/t5" {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusNotFound, map[string]string{"errorCode": "task_not_found"})
+		},
+	)
+	c := newTestClient(t, mux, false)
+	c.rememberInboxId("inbox1")
+
+	task, err := c.GetTaskById("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.ProjectId != "p2" {
+		t.Errorf("Expected the task in p2, got %+v", task)
+	}
+	want := []string{"/open/v1/project/inbox1/task/t1", "/open/v1/project/p1/task/t1", "/open/v1/project/p2/task/t1"}
+	if len(paths) != len(want) {
+		t.Fatalf("Expected the inbox then each project to be tried, got %v", paths)
+	}
+	if _, err := c.GetTaskById("t9"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound for a task in no project, got %v", err)
+	}
+
+	paths = nil
+	if _, err := c.GetTaskById("t5"); err == nil || errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected the server error to be returned, got %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("Expected the scan to stop at the server error, got %v", paths)
+	}
+}
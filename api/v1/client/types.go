@@ -42,15 +42,23 @@ func validateTask(t *tasks.Task) error {
 	if t.Title == "" {
 		return errors.New("Task must have a title")
 	}
-	if t.ProjectId == "" {
-		t.ProjectId = "inbox"
-	}
 	if t.Status.String() == "" {
 		return errors.New("task has invalid Status")
 	}
 	if t.Priority.String() == "" {
 		return errors.New("task has invalid priority")
 	}
+	if t.Kind.String() == "" {
+		return errors.New("task has invalid kind")
+	}
+	if t.Kind == tasks.Note {
+		if len(t.ChecklistItems) > 0 {
+			return errors.New("note cannot have checklist items")
+		}
+		if !t.DueDate.IsZero() || !t.StartDate.IsZero() {
+			return errors.New("note cannot have a start or due date")
+		}
+	}
 	if !t.DueDate.IsZero() && t.StartDate.IsZero() {
 		t.StartDate = t.DueDate
 	}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func TestResolveTaskProject(t *testing.T) {
+	inboxTasks := []map[string]string{}
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/open/v1/project/inbox/data", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			writeJSON(w, http.StatusOK, map[string]interface{}{"id": "inbox", "tasks": inboxTasks})
+		},
+	)
+
+	testCases := []struct {
+		name      string
+		projectID string
+		inboxTask bool
+		want      string
+		requests  int
+	}{
+		{"Empty project id", "", true, "inbox123456", 1},
+		{"Literal inbox", "inbox", true, "inbox123456", 1},
+		{"Unresolved inbox", "", false, "inbox", 1},
+		{"Other project", "6604bd155250b04bff18821f", true, "6604bd155250b04bff18821f", 0},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				requests = 0
+				inboxTasks = nil
+				if tc.inboxTask {
+					inboxTasks = []map[string]string{{"id": "t1", "projectId": "inbox123456"}}
+				}
+				c := newTestClient(t, mux, false)
+				task := tasks.Task{Title: "task", ProjectId: tc.projectID}
+				if err := c.resolveTaskProject(&task); err != nil {
+					t.Fatal(err)
+				}
+				if task.ProjectId != tc.want {
+					t.Errorf("resolveTaskProject() set ProjectId = %q, want %q", task.ProjectId, tc.want)
+				}
+				if requests != tc.requests {
+					t.Errorf("Expected %d requests, got %d", tc.requests, requests)
+				}
+			},
+		)
+	}
+}
+
+func TestTaskValidationBeforeRequests(t *testing.T) {
+	requests := 0
+	c := newTestClient(
+		t, http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		), false,
+	)
+	if err := c.CreateTask(&tasks.Task{}); err == nil {
+		t.Error("Expected a task without a title to be rejected")
+	}
+	if err := c.UpdateTask(&tasks.Task{Title: "task"}); err == nil {
+		t.Error("Expected a task without an id to be rejected")
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests for invalid tasks, got %d", requests)
+	}
+}
@@ -1,23 +1,17 @@
 package client
 
 import (
-	"bytes"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
-	"io"
-	"log"
 	"net"
-	"os"
-	"path"
 	"regexp"
 	"strconv"
 
-	"github.com/zalando/go-keyring"
+	"github.com/herzs11/go-ticktick/api/tokenstore"
 )
 
-const OAUTH2_FILENAME = ".gott_auth2"
+const OAUTH2_FILENAME = tokenstore.FILENAME
 
 func checkPort(port string) bool {
 	// Attempt to listen on the port
@@ -30,75 +24,64 @@ func checkPort(port string) bool {
 	return false
 }
 
-func storeToken(clientID string, token oauthToken) error {
-	err := storeTokenKeyring(clientID, token)
-	if err == nil {
-		return nil
+// loadToken reads the token for clientID from store, failing if it is no
+// longer valid. A token saved before tokens were kept by client id is moved to
+// clientID.
+func loadToken(store tokenstore.Store, clientID string) (*oauthToken, error) {
+	var token oauthToken
+	err := tokenstore.LoadJSON(store, clientID, &token)
+	if errors.Is(err, tokenstore.ErrNotFound) {
+		err = migrateLegacyToken(store, clientID, &token)
 	}
-	log.Printf("Unable to store token in keyring service, saving to ~/.gott_oauth2")
-	return storeTokenFile(token)
-}
-
-func storeTokenKeyring(clientID string, token oauthToken) error {
-	data, err := json.Marshal(token)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !token.validate() {
+		return nil, errors.New("Unable to validate stored token")
 	}
-	return keyring.Set(KEYRING_SERVICE, clientID, string(data))
+	return &token, nil
 }
 
-func storeTokenFile(token oauthToken) error {
-	data, err := json.Marshal(token)
-	if err != nil {
+func migrateLegacyToken(store tokenstore.Store, clientID string, token *oauthToken) error {
+	if err := tokenstore.LoadJSON(store, tokenstore.LEGACY_KEY, token); err != nil {
 		return err
 	}
-	home, err := os.UserHomeDir()
-	if err != nil {
+	if err := storeToken(store, clientID, *token); err != nil {
 		return err
 	}
-	file, err := os.Create(path.Join(home, OAUTH2_FILENAME))
+	return store.Delete(tokenstore.LEGACY_KEY)
+}
+
+func storeToken(store tokenstore.Store, clientID string, token oauthToken) error {
+	return tokenstore.SaveJSON(store, clientID, token)
+}
+
+func storeTokenFile(clientID string, token oauthToken) error {
+	store, err := defaultFileStore()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	_, err = io.Copy(file, bytes.NewBuffer(data))
-	return err
+	return storeToken(store, clientID, token)
 }
 
 func getTokenFromKeyring(clientID string) (*oauthToken, error) {
-	secret, err := keyring.Get(KEYRING_SERVICE, clientID)
-	if err != nil {
-		return nil, err
-	}
-	var retrievedToken oauthToken
-	err = json.Unmarshal([]byte(secret), &retrievedToken)
-	if err != nil {
-		return nil, err
-	}
-	if !retrievedToken.validate() {
-		return nil, errors.New("Unable to validate token from keyring")
-	}
-	return &retrievedToken, nil
+	return loadToken(tokenstore.Keyring{Service: KEYRING_SERVICE}, clientID)
 }
 
-func getTokenFromFile() (*oauthToken, error) {
-	authToken := &oauthToken{}
-	home, err := os.UserHomeDir()
+func getTokenFromFile(clientID string) (*oauthToken, error) {
+	store, err := defaultFileStore()
 	if err != nil {
 		return nil, err
 	}
-	file, err := os.Open(path.Join(home, OAUTH2_FILENAME))
-	if err != nil {
-		return nil, err
-	}
-	err = json.NewDecoder(file).Decode(authToken)
+	return loadToken(store, clientID)
+}
+
+func defaultFileStore() (tokenstore.Store, error) {
+	path, err := tokenstore.DefaultFilePath()
 	if err != nil {
 		return nil, err
 	}
-	if !authToken.validate() {
-		return nil, errors.New("Unable to validate token from keyring")
-	}
-	return authToken, nil
+	return tokenstore.File{Path: path}, nil
 }
 
 func randomHex(n int) (string, error) {
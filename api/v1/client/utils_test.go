@@ -1,7 +1,12 @@
 package client
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/herzs11/go-ticktick/api/tokenstore"
 )
 
 func TestValidateRGBHex(t *testing.T) {
@@ -30,3 +35,29 @@ func TestValidateRGBHex(t *testing.T) {
 		)
 	}
 }
+
+func TestMigrateLegacyToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), OAUTH2_FILENAME)
+	legacy := `{"access_token":"abc","token_type":"bearer","expires_in":86400,"expires_time":1700000000}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store := tokenstore.File{Path: path}
+	var token oauthToken
+	if err := migrateLegacyToken(store, "client1", &token); err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "abc" || token.ExpiresTime != 1700000000 {
+		t.Errorf("Expected the legacy token, got %+v", token)
+	}
+	var migrated oauthToken
+	if err := tokenstore.LoadJSON(store, "client1", &migrated); err != nil || migrated != token {
+		t.Errorf("Expected the token under the client id, got %+v, %v", migrated, err)
+	}
+	if _, err := store.Load(tokenstore.LEGACY_KEY); !errors.Is(err, tokenstore.ErrNotFound) {
+		t.Errorf("Expected the legacy entry to be removed, got %v", err)
+	}
+	if err := migrateLegacyToken(store, "client2", &token); !errors.Is(err, tokenstore.ErrNotFound) {
+		t.Errorf("Expected nothing left to migrate, got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+type Op string
+
+const (
+	OpEq Op = ":"
+	OpNe Op = "!="
+	OpLt Op = "<"
+	OpLe Op = "<="
+	OpGt Op = ">"
+	OpGe Op = ">="
+)
+
+// Env holds what evaluating a query needs beyond the task itself.
+type Env struct {
+	// Now is the time relative dates are resolved against, time.Now() if
+	// zero.
+	Now time.Time
+	// ProjectName returns the name of the project with the given id, for
+	// matching project terms by name. Without it only ids match.
+	ProjectName func(id string) string
+}
+
+func (e *Env) now() time.Time {
+	if e == nil || e.Now.IsZero() {
+		return time.Now()
+	}
+	return e.Now
+}
+
+func (e *Env) projectName(id string) string {
+	if e == nil || e.ProjectName == nil {
+		return ""
+	}
+	return e.ProjectName(id)
+}
+
+// Node is a filter in the syntax tree of a query.
+type Node interface {
+	Match(t *tasks.Task, env *Env) bool
+	String() string
+}
+
+type And struct {
+	Nodes []Node
+}
+
+type Or struct {
+	Nodes []Node
+}
+
+type Not struct {
+	Node Node
+}
+
+// Text matches tasks whose title or body contains Value, ignoring case.
+type Text struct {
+	Value string
+}
+
+// Flag matches tasks with a property named by a bare keyword, such as
+// completed or overdue.
+type Flag struct {
+	Name string
+}
+
+// Term compares a field of the task with a value, e.g. due<=+3d.
+type Term struct {
+	Field string
+	Op    Op
+	Value string
+}
+
+func (n *And) Match(t *tasks.Task, env *Env) bool {
+	for _, c := range n.Nodes {
+		if !c.Match(t, env) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *And) String() string {
+	parts := make([]string, len(n.Nodes))
+	for i, c := range n.Nodes {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func (n *Or) Match(t *tasks.Task, env *Env) bool {
+	for _, c := range n.Nodes {
+		if c.Match(t, env) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Or) String() string {
+	parts := make([]string, len(n.Nodes))
+	for i, c := range n.Nodes {
+		parts[i] = c.String()
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (n *Not) Match(t *tasks.Task, env *Env) bool {
+	return !n.Node.Match(t, env)
+}
+
+func (n *Not) String() string {
+	if _, ok := n.Node.(*And); ok {
+		return "!(" + n.Node.String() + ")"
+	}
+	return "!" + n.Node.String()
+}
+
+func (n *Text) Match(t *tasks.Task, env *Env) bool {
+	v := strings.ToLower(n.Value)
+	return strings.Contains(strings.ToLower(t.Title), v) ||
+		strings.Contains(strings.ToLower(t.Content), v) ||
+		strings.Contains(strings.ToLower(t.Desc), v)
+}
+
+func (n *Text) String() string {
+	return quote(n.Value, true)
+}
+
+var flags = map[string]func(t *tasks.Task, env *Env) bool{
+	"completed": func(t *tasks.Task, env *Env) bool {
+		return t.Status == tasks.Completed
+	},
+	"overdue": func(t *tasks.Task, env *Env) bool {
+		return t.Status != tasks.Completed && !t.DueDate.IsZero() && t.DueDate.Before(env.now())
+	},
+	"subtask": func(t *tasks.Task, env *Env) bool {
+		return t.ParentId != ""
+	},
+	"repeating": func(t *tasks.Task, env *Env) bool {
+		return t.RepeatFlag != ""
+	},
+	"note": func(t *tasks.Task, env *Env) bool {
+		return t.Kind == tasks.Note
+	},
+	"checklist": func(t *tasks.Task, env *Env) bool {
+		return t.Kind == tasks.Checklist || len(t.ChecklistItems) > 0
+	},
+}
+
+func (n *Flag) Match(t *tasks.Task, env *Env) bool {
+	return flags[n.Name](t, env)
+}
+
+func (n *Flag) String() string {
+	return n.Name
+}
+
+// fields lists the ops each term field supports.
+var fields = map[string][]Op{
+	"project":  {OpEq, OpNe},
+	"tag":      {OpEq, OpNe},
+	"title":    {OpEq, OpNe},
+	"kind":     {OpEq, OpNe},
+	"id":       {OpEq, OpNe},
+	"parent":   {OpEq, OpNe},
+	"priority": {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+	"due":      {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+	"start":    {OpEq, OpNe, OpLt, OpLe, OpGt, OpGe},
+}
+
+// validate checks the value of the term, so that evaluating it cannot fail.
+func (n *Term) validate() error {
+	ops, ok := fields[n.Field]
+	if !ok {
+		return errors.New(fmt.Sprintf("unknown field %q", n.Field))
+	}
+	supported := false
+	for _, op := range ops {
+		supported = supported || op == n.Op
+	}
+	if !supported {
+		return errors.New(fmt.Sprintf("field %s does not support %s", n.Field, n.Op))
+	}
+	switch n.Field {
+	case "priority":
+		if _, ok := parsePriority(n.Value); !ok {
+			return errors.New(fmt.Sprintf("invalid priority %q", n.Value))
+		}
+	case "kind":
+		if _, ok := parseKind(n.Value); !ok {
+			return errors.New(fmt.Sprintf("invalid kind %q", n.Value))
+		}
+	case "due", "start":
+		if _, _, err := parseDay(n.Value, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *Term) Match(t *tasks.Task, env *Env) bool {
+	switch n.Field {
+	case "project":
+		v := strings.ToLower(n.Value)
+		return n.eq(t.ProjectId == n.Value || strings.ToLower(env.projectName(t.ProjectId)) == v)
+	case "tag":
+		for _, tag := range t.Tags {
+			if strings.EqualFold(tag, n.Value) {
+				return n.eq(true)
+			}
+		}
+		return n.eq(false)
+	case "title":
+		return n.eq(strings.Contains(strings.ToLower(t.Title), strings.ToLower(n.Value)))
+	case "kind":
+		k, _ := parseKind(n.Value)
+		return n.eq(t.Kind == k)
+	case "id":
+		return n.eq(t.Id == n.Value)
+	case "parent":
+		return n.eq(t.ParentId == n.Value)
+	case "priority":
+		p, _ := parsePriority(n.Value)
+		return compare(int(t.Priority), int(p), n.Op)
+	case "due":
+		return n.matchDay(t.DueDate, env)
+	case "start":
+		return n.matchDay(t.StartDate, env)
+	}
+	return false
+}
+
+func (n *Term) eq(match bool) bool {
+	if n.Op == OpNe {
+		return !match
+	}
+	return match
+}
+
+// matchDay compares dates by calendar day. Tasks without the date only match
+// the value none, or != anything else.
+func (n *Term) matchDay(d time.Time, env *Env) bool {
+	day, none, _ := parseDay(n.Value, env.now())
+	if none {
+		return n.eq(d.IsZero())
+	}
+	if d.IsZero() {
+		return n.Op == OpNe
+	}
+	return compare(dayOf(d).Unix(), day.Unix(), n.Op)
+}
+
+func (n *Term) String() string {
+	return n.Field + string(n.Op) + quote(n.Value, false)
+}
+
+func compare[T int | int64](a, b T, op Op) bool {
+	switch op {
+	case OpEq:
+		return a == b
+	case OpNe:
+		return a != b
+	case OpLt:
+		return a < b
+	case OpLe:
+		return a <= b
+	case OpGt:
+		return a > b
+	case OpGe:
+		return a >= b
+	}
+	return false
+}
+
+func parsePriority(s string) (tasks.Priority, bool) {
+	switch strings.ToLower(s) {
+	case "none", "0":
+		return tasks.None, true
+	case "low", "1":
+		return tasks.Low, true
+	case "medium", "3":
+		return tasks.Medium, true
+	case "high", "5":
+		return tasks.High, true
+	}
+	return 0, false
+}
+
+func parseKind(s string) (tasks.Kind, bool) {
+	switch strings.ToLower(s) {
+	case "text":
+		return tasks.Text, true
+	case "checklist":
+		return tasks.Checklist, true
+	case "note":
+		return tasks.Note, true
+	}
+	return 0, false
+}
+
+func dayOf(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// parseDay resolves a date value: today, tomorrow, yesterday, none, an offset
+// from today such as +3d, -1w or 2d, or a date in 2006-01-02 form.
+func parseDay(s string, now time.Time) (time.Time, bool, error) {
+	today := dayOf(now)
+	switch strings.ToLower(s) {
+	case "none":
+		return time.Time{}, true, nil
+	case "today":
+		return today, false, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), false, nil
+	}
+	if d, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return d, false, nil
+	}
+	if len(s) >= 2 {
+		unit := s[len(s)-1]
+		n, err := strconv.Atoi(strings.TrimPrefix(s[:len(s)-1], "+"))
+		if err == nil {
+			switch unit {
+			case 'd':
+				return today.AddDate(0, 0, n), false, nil
+			case 'w':
+				return today.AddDate(0, 0, 7*n), false, nil
+			case 'm':
+				return today.AddDate(0, n, 0), false, nil
+			}
+		}
+	}
+	return time.Time{}, false, errors.New(fmt.Sprintf("invalid date %q", s))
+}
+
+func quote(s string, text bool) string {
+	needsQuotes := s == "" || strings.ContainsAny(s, " \t\"()")
+	if text {
+		_, isFlag := flags[s]
+		needsQuotes = needsQuotes || isFlag || s == "OR" || s == "AND" || s == "NOT" || strings.ContainsAny(s, ":<>=!")
+	}
+	if needsQuotes {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,350 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SyntaxError reports an invalid query and the byte offset of the problem.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query: %s at position %d", e.Msg, e.Pos)
+}
+
+type tokenKind int
+
+const (
+	tokTerm tokenKind = iota
+	tokText
+	tokLParen
+	tokRParen
+	tokNot
+	tokOr
+	tokAnd
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	field string
+	op    Op
+	value string
+}
+
+// ops is ordered so that two character operators are tried first.
+var ops = []Op{OpLe, OpGe, OpNe, OpEq, OpLt, OpGt, "="}
+
+func isDelim(r rune) bool {
+	return r == '(' || r == ')' || unicode.IsSpace(r)
+}
+
+// readValue reads a bare or quoted value starting at i, returning it, the
+// offset after it and whether it was quoted.
+func readValue(s string, i int) (string, int, bool, error) {
+	if i < len(s) && s[i] == '"' {
+		j := i + 1
+		for j < len(s) && s[j] != '"' {
+			if s[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(s) {
+			return "", 0, false, &SyntaxError{Pos: i, Msg: "unterminated quote"}
+		}
+		v, err := strconv.Unquote(s[i : j+1])
+		if err != nil {
+			return "", 0, false, &SyntaxError{Pos: i, Msg: "invalid quoted string"}
+		}
+		return v, j + 1, true, nil
+	}
+	j := i
+	for j < len(s) {
+		r, size := utf8.DecodeRuneInString(s[j:])
+		if isDelim(r) {
+			break
+		}
+		j += size
+	}
+	return s[i:j], j, false, nil
+}
+
+func lex(s string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(s) {
+		c, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+			continue
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, pos: i})
+			i++
+			continue
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, pos: i})
+			i++
+			continue
+		case c == '!':
+			toks = append(toks, token{kind: tokNot, pos: i})
+			i++
+			continue
+		}
+
+		start := i
+		j := i
+		for j < len(s) {
+			r, size := utf8.DecodeRuneInString(s[j:])
+			if !unicode.IsLetter(r) && r != '_' {
+				break
+			}
+			j += size
+		}
+		if j > i {
+			for _, op := range ops {
+				if strings.HasPrefix(s[j:], string(op)) {
+					v, next, _, err := readValue(s, j+len(op))
+					if err != nil {
+						return nil, err
+					}
+					if op == "=" {
+						op = OpEq
+					}
+					toks = append(
+						toks, token{kind: tokTerm, pos: start, field: strings.ToLower(s[i:j]), op: op, value: v},
+					)
+					i = next
+					break
+				}
+			}
+			if i != start {
+				continue
+			}
+		}
+
+		v, next, quoted, err := readValue(s, i)
+		if err != nil {
+			return nil, err
+		}
+		i = next
+		tok := token{kind: tokText, pos: start, value: v}
+		if !quoted {
+			switch v {
+			case "OR":
+				tok.kind = tokOr
+			case "AND":
+				tok.kind = tokAnd
+			case "NOT":
+				tok.kind = tokNot
+			default:
+				if _, ok := flags[strings.ToLower(v)]; ok {
+					tok.kind = tokTerm
+					tok.field = strings.ToLower(v)
+				}
+			}
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+	end  int
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.toks) {
+		return nil
+	}
+	return &p.toks[p.pos]
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	pos := p.end
+	if t := p.peek(); t != nil {
+		pos = t.pos
+	}
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Node, error) {
+	var nodes []Node
+	for {
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+		if t := p.peek(); t == nil || t.kind != tokOr {
+			break
+		}
+		p.pos++
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var nodes []Node
+	for {
+		t := p.peek()
+		if t == nil || t.kind == tokOr || t.kind == tokRParen {
+			break
+		}
+		if t.kind == tokAnd {
+			p.pos++
+			continue
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, p.errorf("expected a term")
+	case 1:
+		return nodes[0], nil
+	}
+	return &And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	t := p.peek()
+	p.pos++
+	switch t.kind {
+	case tokNot:
+		if p.peek() == nil {
+			return nil, p.errorf("expected a term after !")
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: n}, nil
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.peek(); t == nil || t.kind != tokRParen {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return n, nil
+	case tokText:
+		return &Text{Value: t.value}, nil
+	case tokTerm:
+		if t.op == "" {
+			return &Flag{Name: t.field}, nil
+		}
+		n := &Term{Field: t.field, Op: t.op, Value: t.value}
+		if err := n.validate(); err != nil {
+			return nil, &SyntaxError{Pos: t.pos, Msg: err.Error()}
+		}
+		return n, nil
+	}
+	p.pos--
+	return nil, p.errorf("unexpected token")
+}
+
+// clauses removes the sort and limit clauses from toks, which apply to the
+// whole query wherever they appear, and records them in q.
+func clauses(toks []token, q *Query) ([]token, error) {
+	var rest []token
+	depth := 0
+	for i, t := range toks {
+		switch t.kind {
+		case tokLParen:
+			depth++
+		case tokRParen:
+			depth--
+		}
+		if t.kind != tokTerm || (t.field != "sort" && t.field != "limit") {
+			rest = append(rest, t)
+			continue
+		}
+		if depth > 0 || (i > 0 && toks[i-1].kind == tokNot) || t.op != OpEq {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("%s must be a top level clause", t.field)}
+		}
+		if t.field == "limit" {
+			n, err := strconv.Atoi(t.value)
+			if err != nil || n < 0 {
+				return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("invalid limit %q", t.value)}
+			}
+			q.Limit = n
+			continue
+		}
+		for _, f := range strings.Split(t.value, ",") {
+			key := SortKey{Field: strings.ToLower(strings.TrimPrefix(f, "-")), Desc: strings.HasPrefix(f, "-")}
+			if _, ok := sortFields[key.Field]; !ok {
+				return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("cannot sort by %q", f)}
+			}
+			q.Sort = append(q.Sort, key)
+		}
+	}
+	return rest, nil
+}
+
+// Parse parses a query such as
+//
+//	project:Work tag:urgent due<=+3d priority>=medium !completed sort:due limit:10
+//
+// Terms separated by spaces must all match; OR, ! (or NOT) and parentheses
+// combine them otherwise. Fields are compared with :, !=, <, <=, > and >=.
+// Bare words are flags such as completed, overdue or subtask if they name
+// one, and search the title and body otherwise; quote them to always search.
+func Parse(s string) (*Query, error) {
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{}
+	toks, err = clauses(toks, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return q, nil
+	}
+	p := &parser{toks: toks, end: len(s)}
+	q.Filter, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != nil {
+		return nil, p.errorf("unexpected %s", tokenName(p.peek()))
+	}
+	return q, nil
+}
+
+func MustParse(s string) *Query {
+	q, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func tokenName(t *token) string {
+	switch t.kind {
+	case tokRParen:
+		return ")"
+	case tokLParen:
+		return "("
+	}
+	return "token"
+}
@@ -0,0 +1,144 @@
+// Package query implements a small query language for filtering, sorting and
+// limiting tasks, e.g.
+//
+//	project:Work tag:urgent due<=+3d priority>=medium !completed sort:due limit:10
+//
+// See Parse for the syntax.
+package query
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Query is a parsed query. A nil Filter matches every task and a zero Limit
+// means no limit.
+type Query struct {
+	Filter Node
+	Sort   []SortKey
+	Limit  int
+}
+
+// sortFields compare two tasks by a field, ordering tasks without a date
+// after those with one.
+var sortFields = map[string]func(a, b *tasks.Task, env *Env) int{
+	"due": func(a, b *tasks.Task, env *Env) int {
+		return compareDates(a.DueDate.Unix(), a.DueDate.IsZero(), b.DueDate.Unix(), b.DueDate.IsZero())
+	},
+	"start": func(a, b *tasks.Task, env *Env) int {
+		return compareDates(a.StartDate.Unix(), a.StartDate.IsZero(), b.StartDate.Unix(), b.StartDate.IsZero())
+	},
+	"modified": func(a, b *tasks.Task, env *Env) int {
+		return compareDates(
+			a.ModifiedTime.Unix(), a.ModifiedTime.IsZero(), b.ModifiedTime.Unix(), b.ModifiedTime.IsZero(),
+		)
+	},
+	"priority": func(a, b *tasks.Task, env *Env) int {
+		return int(a.Priority) - int(b.Priority)
+	},
+	"title": func(a, b *tasks.Task, env *Env) int {
+		return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	},
+	"project": func(a, b *tasks.Task, env *Env) int {
+		return strings.Compare(
+			strings.ToLower(env.projectName(a.ProjectId)), strings.ToLower(env.projectName(b.ProjectId)),
+		)
+	},
+	"order": func(a, b *tasks.Task, env *Env) int {
+		switch {
+		case a.SortOrder < b.SortOrder:
+			return -1
+		case a.SortOrder > b.SortOrder:
+			return 1
+		}
+		return 0
+	},
+}
+
+func compareDates(a int64, aZero bool, b int64, bZero bool) int {
+	switch {
+	case aZero && bZero:
+		return 0
+	case aZero:
+		return 1
+	case bZero:
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (q *Query) Match(t *tasks.Task, env *Env) bool {
+	return q.Filter == nil || q.Filter.Match(t, env)
+}
+
+// Apply returns the tasks in ts matching the query, sorted and limited as it
+// asks. Tasks that sort equally keep their order in ts.
+func (q *Query) Apply(ts []tasks.Task, env *Env) []tasks.Task {
+	if env == nil {
+		env = &Env{}
+	}
+	if env.Now.IsZero() {
+		e := *env
+		e.Now = e.now()
+		env = &e
+	}
+	var res []tasks.Task
+	for i := range ts {
+		if q.Match(&ts[i], env) {
+			res = append(res, ts[i])
+		}
+	}
+	if len(q.Sort) > 0 {
+		sort.SliceStable(
+			res, func(i, j int) bool {
+				for _, key := range q.Sort {
+					c := sortFields[key.Field](&res[i], &res[j], env)
+					if key.Desc {
+						c = -c
+					}
+					if c != 0 {
+						return c < 0
+					}
+				}
+				return false
+			},
+		)
+	}
+	if q.Limit > 0 && len(res) > q.Limit {
+		res = res[:q.Limit]
+	}
+	return res
+}
+
+func (q *Query) String() string {
+	var parts []string
+	if q.Filter != nil {
+		parts = append(parts, q.Filter.String())
+	}
+	if len(q.Sort) > 0 {
+		keys := make([]string, len(q.Sort))
+		for i, key := range q.Sort {
+			keys[i] = key.Field
+			if key.Desc {
+				keys[i] = "-" + key.Field
+			}
+		}
+		parts = append(parts, "sort:"+strings.Join(keys, ","))
+	}
+	if q.Limit > 0 {
+		parts = append(parts, "limit:"+strconv.Itoa(q.Limit))
+	}
+	return strings.Join(parts, " ")
+}
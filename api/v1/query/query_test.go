@@ -0,0 +1,145 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"Empty", "", ""},
+		{"Terms", "project:Work tag:urgent due<=+3d", "project:Work tag:urgent due<=+3d"},
+		{"Flags and text", "!completed report", "!completed report"},
+		{"Or", "tag:home OR tag:errands", "(tag:home OR tag:errands)"},
+		{"Grouping", "!(tag:home tag:errands) priority>=medium", "!(tag:home tag:errands) priority>=medium"},
+		{"Quoted", `project:"Side projects" "completed"`, `project:"Side projects" "completed"`},
+		{"Equals", "priority=high", "priority:high"},
+		{"Clauses", "sort:due,-priority tag:urgent limit:5", "tag:urgent sort:due,-priority limit:5"},
+		{"Not keyword", "NOT overdue AND subtask", "!overdue subtask"},
+		{"Non-ASCII text", "voilà Åsa", "voilà Åsa"},
+		{"Non-ASCII value", "title:voilà tag:größe", "title:voilà tag:größe"},
+		{"Non-breaking space", "tag:a\u00a0tag:b", "tag:a tag:b"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				q, err := Parse(tc.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := q.String(); got != tc.want {
+					t.Errorf("Parse(%q).String() = %q, want %q", tc.query, got, tc.want)
+				}
+			},
+		)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+		pos   int
+	}{
+		{"Unknown field", "tag:a colour:red", 6},
+		{"Bad priority", "priority>urgent", 0},
+		{"Bad date", "due<soon", 0},
+		{"Unsupported op", "tag>a", 0},
+		{"Unclosed paren", "(tag:a", 6},
+		{"Stray paren", "tag:a )", 6},
+		{"Unterminated quote", `title:"abc`, 6},
+		{"Nested sort", "(sort:due)", 1},
+		{"Bad limit", "limit:many", 0},
+		{"Bad sort", "sort:colour", 0},
+		{"Dangling or", "tag:a OR", 8},
+		{"Non-ASCII field", "größe:1", 0},
+		{"After non-ASCII text", "voilà colour:red", 7},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				_, err := Parse(tc.query)
+				var se *SyntaxError
+				if !errors.As(err, &se) {
+					t.Fatalf("Expected a syntax error, got %v", err)
+				}
+				if se.Pos != tc.pos {
+					t.Errorf("Expected error at %d, got %d: %s", tc.pos, se.Pos, se)
+				}
+			},
+		)
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, 12, 15, 12, 0, 0, 0, time.Local)
+	ts := []tasks.Task{
+		{
+			Id: "t1", ProjectId: "p1", Title: "Write report", Tags: []string{"Urgent"}, Priority: tasks.High,
+			DueDate: now.AddDate(0, 0, 2),
+		},
+		{Id: "t2", ProjectId: "p1", Title: "Review slides", Priority: tasks.Medium, DueDate: now.AddDate(0, 0, 5)},
+		{Id: "t3", ProjectId: "p2", Title: "Buy milk", Tags: []string{"errands"}, DueDate: now.AddDate(0, 0, -1)},
+		{Id: "t4", ProjectId: "p2", Title: "Call plumber", Status: tasks.Completed},
+		{Id: "t5", ProjectId: "p1", Title: "Outline", ParentId: "t1", Priority: tasks.Low},
+		{Id: "t6", ProjectId: "p2", Title: "Café voilà", Tags: []string{"Größe"}, Status: tasks.Completed},
+	}
+	env := &Env{
+		Now: now,
+		ProjectName: func(id string) string {
+			return map[string]string{"p1": "Work", "p2": "Home"}[id]
+		},
+	}
+
+	testCases := []struct {
+		query string
+		want  []string
+	}{
+		{"project:work", []string{"t1", "t2", "t5"}},
+		{"project:p2 !completed", []string{"t3"}},
+		{"tag:urgent", []string{"t1"}},
+		{"tag!=urgent", []string{"t2", "t3", "t4", "t5", "t6"}},
+		{"due<=+3d", []string{"t1", "t3"}},
+		{"due:none", []string{"t4", "t5", "t6"}},
+		{"due:tomorrow OR due:2024-12-20", []string{"t2"}},
+		{"priority>=medium", []string{"t1", "t2"}},
+		{"overdue", []string{"t3"}},
+		{"subtask", []string{"t5"}},
+		{"completed", []string{"t4", "t6"}},
+		{"voilà", []string{"t6"}},
+		{"title:café", []string{"t6"}},
+		{"tag:größe", []string{"t6"}},
+		{"REPORT", []string{"t1"}},
+		{"project:Work sort:-priority limit:2", []string{"t1", "t2"}},
+		{"sort:due", []string{"t3", "t1", "t2", "t4", "t5", "t6"}},
+		{"sort:project,title", []string{"t3", "t6", "t4", "t5", "t2", "t1"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.query, func(t *testing.T) {
+				var got []string
+				for _, task := range MustParse(tc.query).Apply(ts, env) {
+					got = append(got, task.Id)
+				}
+				if len(got) != len(tc.want) {
+					t.Fatalf("Expected %v, got %v", tc.want, got)
+				}
+				for i := range got {
+					if got[i] != tc.want[i] {
+						t.Fatalf("Expected %v, got %v", tc.want, got)
+					}
+				}
+			},
+		)
+	}
+}
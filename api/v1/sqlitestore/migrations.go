@@ -0,0 +1,141 @@
+package sqlitestore
+
+import (
+	"database/sql"
+)
+
+// migrations are applied in order; the index of a migration plus one is the
+// schema version it produces. Existing entries must never be edited, only
+// appended to.
+var migrations = []string{
+	`CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	CREATE TABLE projects (
+		id        TEXT PRIMARY KEY,
+		name      TEXT NOT NULL,
+		color     TEXT NOT NULL DEFAULT '',
+		view_mode INTEGER NOT NULL DEFAULT 0,
+		kind      INTEGER NOT NULL DEFAULT 0,
+		group_id  TEXT NOT NULL DEFAULT '',
+		closed    INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE tasks (
+		id             TEXT PRIMARY KEY,
+		project_id     TEXT NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+		parent_id      TEXT NOT NULL DEFAULT '',
+		title          TEXT NOT NULL DEFAULT '',
+		content        TEXT NOT NULL DEFAULT '',
+		description    TEXT NOT NULL DEFAULT '',
+		kind           INTEGER NOT NULL DEFAULT 0,
+		is_all_day     INTEGER NOT NULL DEFAULT 0,
+		start_date     TEXT,
+		due_date       TEXT,
+		completed_time TEXT,
+		priority       INTEGER NOT NULL DEFAULT 0,
+		status         INTEGER NOT NULL DEFAULT 0,
+		repeat_flag    TEXT NOT NULL DEFAULT '',
+		sort_order     INTEGER NOT NULL DEFAULT 0,
+		time_zone      TEXT NOT NULL DEFAULT '',
+		reminders      TEXT NOT NULL DEFAULT '[]'
+	);
+	CREATE INDEX tasks_project_id ON tasks (project_id);
+	CREATE INDEX tasks_parent_id ON tasks (parent_id);
+	CREATE INDEX tasks_due_date ON tasks (due_date);
+	CREATE TABLE checklist_items (
+		task_id        TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+		position       INTEGER NOT NULL,
+		id             TEXT NOT NULL DEFAULT '',
+		title          TEXT NOT NULL DEFAULT '',
+		status         INTEGER NOT NULL DEFAULT 0,
+		completed_time TEXT,
+		is_all_day     INTEGER NOT NULL DEFAULT 0,
+		sort_order     INTEGER NOT NULL DEFAULT 0,
+		start_date     TEXT,
+		time_zone      TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (task_id, position)
+	);
+	CREATE TABLE tags (
+		name TEXT PRIMARY KEY
+	);
+	CREATE TABLE task_tags (
+		task_id TEXT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+		tag     TEXT NOT NULL,
+		PRIMARY KEY (task_id, tag)
+	);
+	CREATE INDEX task_tags_tag ON task_tags (tag);`,
+	`CREATE TABLE queued_ops (
+		position  INTEGER PRIMARY KEY,
+		op        TEXT NOT NULL,
+		task      TEXT NOT NULL,
+		queued_at TEXT NOT NULL
+	);`,
+	`ALTER TABLE tasks ADD COLUMN etag TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tasks ADD COLUMN modified_time TEXT;`,
+	`CREATE TABLE filters (
+		position      INTEGER PRIMARY KEY,
+		id            TEXT NOT NULL,
+		name          TEXT NOT NULL,
+		rule          TEXT NOT NULL,
+		sort_order    INTEGER NOT NULL DEFAULT 0,
+		sort_type     TEXT NOT NULL DEFAULT '',
+		view_mode     TEXT NOT NULL DEFAULT '',
+		etag          TEXT NOT NULL DEFAULT '',
+		created_time  TEXT,
+		modified_time TEXT
+	);`,
+	`CREATE TABLE account_tags (
+		position   INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL UNIQUE,
+		raw_name   TEXT NOT NULL DEFAULT '',
+		label      TEXT NOT NULL DEFAULT '',
+		color      TEXT NOT NULL DEFAULT '',
+		parent     TEXT NOT NULL DEFAULT '',
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		sort_type  TEXT NOT NULL DEFAULT '',
+		view_mode  TEXT NOT NULL DEFAULT '',
+		type       INTEGER NOT NULL DEFAULT 0,
+		etag       TEXT NOT NULL DEFAULT ''
+	);`,
+	`ALTER TABLE tasks ADD COLUMN row_hash TEXT NOT NULL DEFAULT '';`,
+	`ALTER TABLE tasks ADD COLUMN reminder_ids TEXT;`,
+	// Clearing the hashes makes the next save rewrite every task with the
+	// fixed-width time format.
+	`UPDATE tasks SET row_hash = '';`,
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)")
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	err = db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+func migrate(db *sql.DB) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
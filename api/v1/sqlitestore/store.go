@@ -0,0 +1,641 @@
+// Package sqlitestore is a TickTickState store backed by an embedded SQLite
+// database. The tables are plain and documented in migrations, so other tools
+// can query the same file with SQL.
+package sqlitestore
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/client"
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+	_ "modernc.org/sqlite"
+)
+
+// Times are stored as fixed-width RFC 3339 strings in UTC so they sort and
+// compare correctly in SQL; zero times are stored as NULL. Databases written
+// before may still hold RFC 3339 times with trimmed fractions, which are read
+// as well.
+const TIME_FORMAT = "2006-01-02T15:04:05.000000000Z"
+
+type Store struct {
+	db *sql.DB
+}
+
+var _ client.StateStore = (*Store)(nil)
+
+// Open opens or creates the database at path and migrates it to the latest
+// schema.
+func Open(path string) (*Store, error) {
+	// Pragmas in the DSN apply to every connection the pool opens.
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// DB returns the underlying database for running queries against it.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func formatTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(TIME_FORMAT), Valid: true}
+}
+
+func parseTime(s sql.NullString) time.Time {
+	if !s.Valid {
+		return time.Time{}
+	}
+	t, err := time.Parse(TIME_FORMAT, s.String)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339Nano, s.String)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
+
+func getMeta(tx *sql.Tx, key string) (string, bool, error) {
+	var v string
+	err := tx.QueryRow("SELECT value FROM meta WHERE key = ?", key).Scan(&v)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	return v, err == nil, err
+}
+
+func setMeta(tx *sql.Tx, key, value string) error {
+	_, err := tx.Exec(
+		"INSERT INTO meta (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value",
+		key, value,
+	)
+	return err
+}
+
+// SaveSnapshot replaces the stored state with s in a single transaction.
+// Projects and tasks are only written if they changed since the last save,
+// and only the ones no longer in s are deleted. The queue, filters and
+// account tags are small and rewritten whole.
+func (s *Store) SaveSnapshot(snap *client.Snapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	projectIDs := map[string]bool{}
+	taskIDs := map[string]bool{}
+	hashes, err := loadTaskHashes(tx)
+	if err != nil {
+		return err
+	}
+	for _, p := range snap.Projects {
+		if err := upsertProject(tx, p); err != nil {
+			return err
+		}
+		projectIDs[p.Id] = true
+	}
+	for _, p := range snap.Projects {
+		for _, t := range p.Tasks {
+			if err := upsertTask(tx, &t, hashes[t.Id]); err != nil {
+				return err
+			}
+			taskIDs[t.Id] = true
+		}
+	}
+	if err := deleteMissing(tx, "tasks", "id", taskIDs); err != nil {
+		return err
+	}
+	if err := deleteMissing(tx, "projects", "id", projectIDs); err != nil {
+		return err
+	}
+	tagNames := map[string]bool{}
+	for _, tag := range snap.Tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", tag); err != nil {
+			return err
+		}
+		tagNames[tag] = true
+	}
+	if err := deleteMissing(tx, "tags", "name", tagNames); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"queued_ops", "filters", "account_tags"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return err
+		}
+	}
+	for i, op := range snap.Queue {
+		if err := insertQueuedOp(tx, i, op); err != nil {
+			return err
+		}
+	}
+	for i, f := range snap.Filters {
+		if err := insertFilter(tx, i, f); err != nil {
+			return err
+		}
+	}
+	for i, t := range snap.AccountTags {
+		_, err := tx.Exec(
+			`INSERT INTO account_tags (
+				position, name, raw_name, label, color, parent, sort_order, sort_type, view_mode, type, etag
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			i, t.Name, t.RawName, t.Label, t.Color, t.Parent, t.SortOrder, t.SortType, t.ViewMode, t.Type, t.Etag,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	meta := map[string]string{
+		"snapshot_schema_version": strconv.Itoa(snap.SchemaVersion),
+		"checkpoint":              strconv.FormatInt(snap.CheckPoint, 10),
+		"saved_at":                snap.SavedAt.UTC().Format(TIME_FORMAT),
+	}
+	for k, v := range meta {
+		if err := setMeta(tx, k, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteMissing deletes the rows of table whose key column is not in keep.
+func deleteMissing(tx *sql.Tx, table, column string, keep map[string]bool) error {
+	rows, err := tx.Query("SELECT " + column + " FROM " + table)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return err
+		}
+		if !keep[key] {
+			stale = append(stale, key)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE "+column+" = ?", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Queued operations keep the task as JSON, as it is only read back whole
+// when the queue is replayed.
+func insertQueuedOp(tx *sql.Tx, position int, op client.QueuedOp) error {
+	task, err := json.Marshal(&op.Task)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO queued_ops (position, op, task, queued_at) VALUES (?, ?, ?, ?)",
+		position, string(op.Op), string(task), op.QueuedAt.UTC().Format(TIME_FORMAT),
+	)
+	return err
+}
+
+// Filter rules are kept as the JSON TickTick uses for them.
+func insertFilter(tx *sql.Tx, position int, f *filter.Filter) error {
+	rule, err := f.EncodeRule()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO filters (
+			position, id, name, rule, sort_order, sort_type, view_mode, etag, created_time, modified_time
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		position, f.Id, f.Name, rule, f.SortOrder, f.SortType, f.ViewMode, f.Etag,
+		formatTime(f.CreatedTime), formatTime(f.ModifiedTime),
+	)
+	return err
+}
+
+func upsertProject(tx *sql.Tx, p *project.Project) error {
+	_, err := tx.Exec(
+		`INSERT INTO projects (id, name, color, view_mode, kind, group_id, closed)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name, color = excluded.color, view_mode = excluded.view_mode, kind = excluded.kind,
+			group_id = excluded.group_id, closed = excluded.closed
+		WHERE (name, color, view_mode, kind, group_id, closed) IS NOT
+			(excluded.name, excluded.color, excluded.view_mode, excluded.kind, excluded.group_id, excluded.closed)`,
+		p.Id, p.Name, p.Color, int(p.ViewMode), int(p.Kind), p.GroupId, p.Closed,
+	)
+	return err
+}
+
+func loadTaskHashes(tx *sql.Tx) (map[string]string, error) {
+	rows, err := tx.Query("SELECT id, row_hash FROM tasks")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hashes := map[string]string{}
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// taskRows returns the values stored for t in the tasks, checklist_items and
+// task_tags tables, and a hash of them to tell whether the stored rows are up
+// to date.
+func taskRows(t *tasks.Task) ([]interface{}, [][]interface{}, string, error) {
+	reminders, err := json.Marshal(t.Reminders)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	var reminderIDs sql.NullString
+	if t.ReminderIds != nil {
+		data, err := json.Marshal(t.ReminderIds)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		reminderIDs = sql.NullString{String: string(data), Valid: true}
+	}
+	row := []interface{}{
+		t.Id, t.ProjectId, t.ParentId, t.Title, t.Content, t.Desc, int(t.Kind), t.IsAllDay,
+		formatTime(t.StartDate), formatTime(t.DueDate), formatTime(t.CompletedTime), int(t.Priority),
+		int(t.Status), t.RepeatFlag, t.SortOrder, t.TimeZone, string(reminders), reminderIDs, t.Etag,
+		formatTime(t.ModifiedTime),
+	}
+	var items [][]interface{}
+	for i, cl := range t.ChecklistItems {
+		items = append(
+			items, []interface{}{
+				t.Id, i, cl.Id, cl.Title, int(cl.Status), formatTime(cl.CompletedTime), cl.IsAllDay, cl.SortOrder,
+				formatTime(cl.StartDate), cl.TimeZone,
+			},
+		)
+	}
+	data, err := json.Marshal([]interface{}{row, items, t.Tags})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return row, items, hex.EncodeToString(sum[:]), nil
+}
+
+// upsertTask writes t and its checklist items and tags, unless storedHash
+// shows the stored rows already match it.
+func upsertTask(tx *sql.Tx, t *tasks.Task, storedHash string) error {
+	row, items, hash, err := taskRows(t)
+	if err != nil {
+		return err
+	}
+	if hash == storedHash {
+		return nil
+	}
+	_, err = tx.Exec(
+		`INSERT INTO tasks (
+			id, project_id, parent_id, title, content, description, kind, is_all_day, start_date, due_date,
+			completed_time, priority, status, repeat_flag, sort_order, time_zone, reminders, reminder_ids, etag,
+			modified_time, row_hash
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			project_id = excluded.project_id, parent_id = excluded.parent_id, title = excluded.title,
+			content = excluded.content, description = excluded.description, kind = excluded.kind,
+			is_all_day = excluded.is_all_day, start_date = excluded.start_date, due_date = excluded.due_date,
+			completed_time = excluded.completed_time, priority = excluded.priority, status = excluded.status,
+			repeat_flag = excluded.repeat_flag, sort_order = excluded.sort_order, time_zone = excluded.time_zone,
+			reminders = excluded.reminders, reminder_ids = excluded.reminder_ids, etag = excluded.etag, modified_time = excluded.modified_time,
+			row_hash = excluded.row_hash`,
+		append(row, hash)...,
+	)
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{"checklist_items", "task_tags"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE task_id = ?", t.Id); err != nil {
+			return err
+		}
+	}
+	for _, item := range items {
+		_, err := tx.Exec(
+			`INSERT INTO checklist_items (
+				task_id, position, id, title, status, completed_time, is_all_day, sort_order, start_date, time_zone
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			item...,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for _, tag := range t.Tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)", t.Id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSnapshot reads the stored state back, or returns nil if nothing has
+// been saved.
+func (s *Store) LoadSnapshot() (*client.Snapshot, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	version, ok, err := getMeta(tx, "snapshot_schema_version")
+	if err != nil || !ok {
+		return nil, err
+	}
+	snap := &client.Snapshot{}
+	if snap.SchemaVersion, err = strconv.Atoi(version); err != nil {
+		return nil, err
+	}
+	checkPoint, _, err := getMeta(tx, "checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	if snap.CheckPoint, err = strconv.ParseInt(checkPoint, 10, 64); err != nil {
+		return nil, err
+	}
+	savedAt, _, err := getMeta(tx, "saved_at")
+	if err != nil {
+		return nil, err
+	}
+	snap.SavedAt = parseTime(sql.NullString{String: savedAt, Valid: savedAt != ""})
+
+	if snap.Projects, err = loadProjects(tx); err != nil {
+		return nil, err
+	}
+	if snap.Queue, err = loadQueue(tx); err != nil {
+		return nil, err
+	}
+	if snap.Filters, err = loadFilters(tx); err != nil {
+		return nil, err
+	}
+	if snap.AccountTags, err = loadAccountTags(tx); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query("SELECT name FROM tags ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		snap.Tags = append(snap.Tags, tag)
+	}
+	return snap, rows.Err()
+}
+
+func loadQueue(tx *sql.Tx) ([]client.QueuedOp, error) {
+	rows, err := tx.Query("SELECT op, task, queued_at FROM queued_ops ORDER BY position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var queue []client.QueuedOp
+	for rows.Next() {
+		var (
+			op             client.QueuedOp
+			kind, task, at string
+		)
+		if err := rows.Scan(&kind, &task, &at); err != nil {
+			return nil, err
+		}
+		op.Op = client.OpKind(kind)
+		if err := json.Unmarshal([]byte(task), &op.Task); err != nil {
+			return nil, err
+		}
+		op.QueuedAt = parseTime(sql.NullString{String: at, Valid: true})
+		queue = append(queue, op)
+	}
+	return queue, rows.Err()
+}
+
+func loadFilters(tx *sql.Tx) ([]*filter.Filter, error) {
+	rows, err := tx.Query(
+		`SELECT id, name, rule, sort_order, sort_type, view_mode, etag, created_time, modified_time
+		FROM filters ORDER BY position`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var fs []*filter.Filter
+	for rows.Next() {
+		var (
+			f                 filter.Filter
+			rule              string
+			created, modified sql.NullString
+		)
+		err := rows.Scan(
+			&f.Id, &f.Name, &rule, &f.SortOrder, &f.SortType, &f.ViewMode, &f.Etag, &created, &modified,
+		)
+		if err != nil {
+			return nil, err
+		}
+		f.DecodeRule(rule)
+		f.CreatedTime = parseTime(created)
+		f.ModifiedTime = parseTime(modified)
+		fs = append(fs, &f)
+	}
+	return fs, rows.Err()
+}
+
+func loadAccountTags(tx *sql.Tx) ([]*tag.Tag, error) {
+	rows, err := tx.Query(
+		`SELECT name, raw_name, label, color, parent, sort_order, sort_type, view_mode, type, etag
+		FROM account_tags ORDER BY position`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []*tag.Tag
+	for rows.Next() {
+		var t tag.Tag
+		err := rows.Scan(
+			&t.Name, &t.RawName, &t.Label, &t.Color, &t.Parent, &t.SortOrder, &t.SortType, &t.ViewMode, &t.Type, &t.Etag,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	return tags, rows.Err()
+}
+
+func loadProjects(tx *sql.Tx) ([]*project.Project, error) {
+	rows, err := tx.Query("SELECT id, name, color, view_mode, kind, group_id, closed FROM projects ORDER BY rowid")
+	if err != nil {
+		return nil, err
+	}
+	var projs []*project.Project
+	byID := map[string]*project.Project{}
+	for rows.Next() {
+		var (
+			p              project.Project
+			viewMode, kind int
+		)
+		if err := rows.Scan(&p.Id, &p.Name, &p.Color, &viewMode, &kind, &p.GroupId, &p.Closed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.ViewMode = project.ViewMode(viewMode)
+		p.Kind = project.Kind(kind)
+		projs = append(projs, &p)
+		byID[p.Id] = &p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ts, err := loadTasks(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range ts {
+		if p, ok := byID[t.ProjectId]; ok {
+			p.Tasks = append(p.Tasks, t)
+		}
+	}
+	return projs, nil
+}
+
+func loadTasks(tx *sql.Tx) ([]tasks.Task, error) {
+	rows, err := tx.Query(
+		`SELECT id, project_id, parent_id, title, content, description, kind, is_all_day, start_date, due_date,
+			completed_time, priority, status, repeat_flag, sort_order, time_zone, reminders, reminder_ids, etag,
+			modified_time
+		FROM tasks ORDER BY sort_order, id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var ts []tasks.Task
+	byID := map[string]int{}
+	for rows.Next() {
+		var (
+			t                               tasks.Task
+			kind, priority, status          int
+			start, due, completed, modified sql.NullString
+			reminders                       string
+			reminderIDs                     sql.NullString
+		)
+		err := rows.Scan(
+			&t.Id, &t.ProjectId, &t.ParentId, &t.Title, &t.Content, &t.Desc, &kind, &t.IsAllDay, &start, &due,
+			&completed, &priority, &status, &t.RepeatFlag, &t.SortOrder, &t.TimeZone, &reminders, &reminderIDs,
+			&t.Etag, &modified,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t.Kind = tasks.Kind(kind)
+		t.Priority = tasks.Priority(priority)
+		t.Status = tasks.Status(status)
+		t.StartDate = parseTime(start)
+		t.DueDate = parseTime(due)
+		t.CompletedTime = parseTime(completed)
+		t.ModifiedTime = parseTime(modified)
+		if err := json.Unmarshal([]byte(reminders), &t.Reminders); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if reminderIDs.Valid {
+			if err := json.Unmarshal([]byte(reminderIDs.String), &t.ReminderIds); err != nil {
+				rows.Close()
+				return nil, err
+			}
+		}
+		byID[t.Id] = len(ts)
+		ts = append(ts, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	items, err := tx.Query(
+		`SELECT task_id, id, title, status, completed_time, is_all_day, sort_order, start_date, time_zone
+		FROM checklist_items ORDER BY task_id, position`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for items.Next() {
+		var (
+			taskID           string
+			cl               tasks.ChecklistItem
+			status           int
+			completed, start sql.NullString
+		)
+		err := items.Scan(
+			&taskID, &cl.Id, &cl.Title, &status, &completed, &cl.IsAllDay, &cl.SortOrder, &start, &cl.TimeZone,
+		)
+		if err != nil {
+			items.Close()
+			return nil, err
+		}
+		cl.Status = tasks.Status(status)
+		cl.CompletedTime = parseTime(completed)
+		cl.StartDate = parseTime(start)
+		if i, ok := byID[taskID]; ok {
+			ts[i].ChecklistItems = append(ts[i].ChecklistItems, cl)
+		}
+	}
+	items.Close()
+	if err := items.Err(); err != nil {
+		return nil, err
+	}
+
+	tags, err := tx.Query("SELECT task_id, tag FROM task_tags ORDER BY task_id, rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer tags.Close()
+	for tags.Next() {
+		var taskID, tag string
+		if err := tags.Scan(&taskID, &tag); err != nil {
+			return nil, err
+		}
+		if i, ok := byID[taskID]; ok {
+			ts[i].Tags = append(ts[i].Tags, tag)
+		}
+	}
+	return ts, tags.Err()
+}
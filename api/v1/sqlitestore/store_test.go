@@ -0,0 +1,292 @@
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/client"
+	"github.com/herzs11/go-ticktick/api/v1/types/filter"
+	"github.com/herzs11/go-ticktick/api/v1/types/project"
+	"github.com/herzs11/go-ticktick/api/v1/types/tag"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func testSnapshot() *client.Snapshot {
+	due := time.Date(2024, 12, 15, 18, 30, 0, 0, time.UTC).Local()
+	return &client.Snapshot{
+		SchemaVersion: client.STATE_SCHEMA_VERSION,
+		SavedAt:       time.Now(),
+		CheckPoint:    1734223054959,
+		Tags:          []string{"urgent"},
+		Queue: []client.QueuedOp{
+			{Op: client.OpCreateTask, Task: tasks.Task{Id: "local-1", ProjectId: "p1", Title: "Call"}},
+			{Op: client.OpCompleteTask, Task: tasks.Task{Id: "t2", ProjectId: "p1", Status: tasks.Completed}},
+		},
+		AccountTags: []*tag.Tag{
+			{Name: "urgent", Label: "Urgent", Color: "#FF0000", SortOrder: -65536},
+			{Name: "later", Label: "Later", Parent: "urgent"},
+		},
+		Filters: []*filter.Filter{
+			{
+				Id:   "f1",
+				Name: "Urgent work",
+				Rule: filter.Rule{
+					And: []filter.Condition{
+						{Name: filter.ListCondition, Or: filter.Values{"p1"}},
+						{Name: filter.TagCondition, Or: filter.Values{"urgent"}},
+					},
+				},
+				SortType: "dueDate",
+			},
+			{Id: "f2", Name: "Offset", RawRule: `{"and":[{"or":[{"offset":1}],"conditionName":"dueDate"}],"type":1}`},
+		},
+		Projects: []*project.Project{
+			{
+				Id:       "p1",
+				Name:     "Work",
+				ViewMode: project.Kanban,
+				Tasks: []tasks.Task{
+					{
+						Id:          "t1",
+						ProjectId:   "p1",
+						Title:       "Write report",
+						DueDate:     due,
+						Etag:        "wjuq5lv2",
+						Reminders:   []string{"TRIGGER:-PT10M"},
+						ReminderIds: map[string]string{"TRIGGER:-PT10M": "r1"},
+						Priority:    tasks.High,
+						Tags:        []string{"urgent"},
+						ChecklistItems: []tasks.ChecklistItem{
+							{Id: "c1", Title: "Outline"},
+							{Id: "c2", Title: "Draft", Status: tasks.Completed},
+						},
+					},
+					{Id: "t2", ProjectId: "p1", Title: "Subtask", ParentId: "t1", SortOrder: 1},
+				},
+			},
+		},
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := s.LoadSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty != nil {
+		t.Fatal("Expected no snapshot in a new database")
+	}
+
+	if err := s.SaveSnapshot(testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveSnapshot(testSnapshot()); err != nil {
+		t.Fatalf("Expected saving twice to replace the state: %s", err)
+	}
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	snap, err := s.LoadSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.CheckPoint != 1734223054959 || len(snap.Projects) != 1 || len(snap.Tags) != 1 {
+		t.Fatalf("Unexpected snapshot %+v", snap)
+	}
+	p := snap.Projects[0]
+	if p.ViewMode != project.Kanban || len(p.Tasks) != 2 {
+		t.Fatalf("Unexpected project %+v", p)
+	}
+	task := p.Tasks[0]
+	want := testSnapshot().Projects[0].Tasks[0]
+	if task.Id != "t1" || !task.DueDate.Equal(want.DueDate) || task.Priority != tasks.High || task.Etag != want.Etag {
+		t.Fatalf("Unexpected task %+v", task)
+	}
+	if !reflect.DeepEqual(task.ReminderIds, want.ReminderIds) || p.Tasks[1].ReminderIds != nil {
+		t.Fatalf("Expected reminder ids to round trip, got %v and %v", task.ReminderIds, p.Tasks[1].ReminderIds)
+	}
+	if len(task.ChecklistItems) != 2 || task.ChecklistItems[1].Status != tasks.Completed {
+		t.Fatalf("Unexpected checklist items %+v", task.ChecklistItems)
+	}
+	if len(task.Tags) != 1 || task.Tags[0] != "urgent" || p.Tasks[1].ParentId != "t1" {
+		t.Fatalf("Unexpected tags or parent %+v %+v", task.Tags, p.Tasks[1])
+	}
+	if len(snap.Queue) != 2 || snap.Queue[0].Op != client.OpCreateTask || snap.Queue[0].Task.Title != "Call" ||
+		snap.Queue[1].Task.Status != tasks.Completed {
+		t.Fatalf("Unexpected queue %+v", snap.Queue)
+	}
+	if len(snap.Filters) != 2 || snap.Filters[0].SortType != "dueDate" || len(snap.Filters[0].Rule.And) != 2 ||
+		snap.Filters[0].Rule.And[1].Or[0] != "urgent" || snap.Filters[1].RawRule != testSnapshot().Filters[1].RawRule {
+		t.Fatalf("Unexpected filters %+v", snap.Filters)
+	}
+	if len(snap.AccountTags) != 2 || snap.AccountTags[0].Color != "#FF0000" || snap.AccountTags[1].Parent != "urgent" {
+		t.Fatalf("Unexpected account tags %+v", snap.AccountTags)
+	}
+}
+
+func TestStoreSQL(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.SaveSnapshot(testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	var title string
+	err = s.DB().QueryRow(
+		`SELECT t.title FROM tasks t JOIN task_tags tt ON tt.task_id = t.id
+		WHERE tt.tag = 'urgent' AND t.due_date < '2025-01-01'`,
+	).Scan(&title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Write report" {
+		t.Fatalf("Expected Write report, got %s", title)
+	}
+}
+
+func TestStoreTimesSort(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	noon := time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)
+	snap := testSnapshot()
+	snap.Projects[0].Tasks = []tasks.Task{
+		{Id: "t1", ProjectId: "p1", Title: "Later", DueDate: noon.Add(500 * time.Millisecond)},
+		{Id: "t2", ProjectId: "p1", Title: "Noon", DueDate: noon.In(time.FixedZone("CET", 3600))},
+		{Id: "t3", ProjectId: "p1", Title: "Latest", DueDate: noon.Add(time.Second)},
+	}
+	if err := s.SaveSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := s.DB().Query("SELECT id FROM tasks ORDER BY due_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if want := []string{"t2", "t1", "t3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected tasks sorted by due date as %v, got %v", want, ids)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 12, 15, 12, 0, 0, 500000000, time.UTC)
+	testCases := []string{"2024-12-15T12:00:00.500000000Z", "2024-12-15T12:00:00.5Z", "2024-12-15T13:00:00.5+01:00"}
+	for _, tc := range testCases {
+		if got := parseTime(sql.NullString{String: tc, Valid: true}); !got.Equal(want) {
+			t.Errorf("Expected %s to parse as %s, got %s", tc, want, got)
+		}
+	}
+}
+
+func TestStoreSavesOnlyChanges(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.SaveSnapshot(testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.DB().Exec(
+		`CREATE TABLE task_writes (id TEXT NOT NULL);
+		CREATE TRIGGER task_inserted AFTER INSERT ON tasks BEGIN INSERT INTO task_writes VALUES (new.id); END;
+		CREATE TRIGGER task_updated AFTER UPDATE ON tasks BEGIN INSERT INTO task_writes VALUES (new.id); END;`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap := testSnapshot()
+	p := snap.Projects[0]
+	p.Tasks[0].Title = "Write the report"
+	p.Tasks = p.Tasks[:1]
+	snap.Projects = append(
+		snap.Projects, &project.Project{Id: "p2", Name: "Home", Tasks: []tasks.Task{{Id: "t3", ProjectId: "p2", Title: "Buy milk"}}},
+	)
+	if err := s.SaveSnapshot(snap); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := s.DB().Query("SELECT id FROM task_writes ORDER BY rowid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writes []string
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		writes = append(writes, id)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(writes, []string{"t1", "t3"}) {
+		t.Errorf("Expected only the changed and new tasks to be written, got %v", writes)
+	}
+
+	loaded, err := s.LoadSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Projects) != 2 || len(loaded.Projects[0].Tasks) != 1 || len(loaded.Projects[1].Tasks) != 1 {
+		t.Fatalf("Expected t2 to be deleted and t3 added, got %+v", loaded.Projects)
+	}
+	if task := loaded.Projects[0].Tasks[0]; task.Title != "Write the report" || len(task.ChecklistItems) != 2 || len(task.Tags) != 1 {
+		t.Errorf("Expected t1 updated with its items and tags, got %+v", task)
+	}
+
+	if err := s.SaveSnapshot(&client.Snapshot{SchemaVersion: client.STATE_SCHEMA_VERSION}); err != nil {
+		t.Fatal(err)
+	}
+	var remaining int
+	s.DB().QueryRow("SELECT (SELECT COUNT(*) FROM projects) + (SELECT COUNT(*) FROM tasks) + (SELECT COUNT(*) FROM checklist_items) + (SELECT COUNT(*) FROM task_tags)").Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected an empty snapshot to remove every row, %d left", remaining)
+	}
+}
+
+func TestStoreForeignKeysOnEveryConnection(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.DB().SetMaxOpenConns(2)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		conn, err := s.DB().Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var enabled int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+			t.Fatal(err)
+		}
+		if enabled != 1 {
+			t.Errorf("Expected foreign keys on connection %d", i)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/query"
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+func (v *Values) UnmarshalJSON(data []byte) error {
+	var raw []interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*v = make(Values, 0, len(raw))
+	for _, r := range raw {
+		switch x := r.(type) {
+		case string:
+			*v = append(*v, x)
+		case float64:
+			*v = append(*v, strconv.FormatFloat(x, 'f', -1, 64))
+		default:
+			// Dropping the value would change the filter when it is saved
+			// back, so refuse it instead.
+			return errors.New(fmt.Sprintf("unsupported filter condition value %v of type %T", r, r))
+		}
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TIME_FORMAT)
+}
+
+func parseTime(s string) time.Time {
+	t, err := time.Parse(TIME_FORMAT, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
+
+// EncodeRule returns the rule as the JSON string TickTick uses for it.
+func (f *Filter) EncodeRule() (string, error) {
+	if f.RawRule != "" {
+		return f.RawRule, nil
+	}
+	rule, err := json.Marshal(f.Rule)
+	if err != nil {
+		return "", err
+	}
+	return string(rule), nil
+}
+
+// DecodeRule sets the rule from its JSON string. Rules that do not decode are
+// kept in RawRule, so one unexpected filter does not fail a whole sync.
+func (f *Filter) DecodeRule(rule string) {
+	f.Rule, f.RawRule = Rule{}, ""
+	if rule == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(rule), &f.Rule); err != nil {
+		f.Rule, f.RawRule = Rule{}, rule
+	}
+}
+
+// MarshalJSON encodes the filter as TickTick expects, with the rule as a JSON
+// string.
+func (f *Filter) MarshalJSON() ([]byte, error) {
+	rule, err := f.EncodeRule()
+	if err != nil {
+		return nil, err
+	}
+	fj := filterJSON{
+		Id:           f.Id,
+		Name:         f.Name,
+		Rule:         rule,
+		SortOrder:    f.SortOrder,
+		SortType:     f.SortType,
+		ViewMode:     f.ViewMode,
+		Etag:         f.Etag,
+		CreatedTime:  formatTime(f.CreatedTime),
+		ModifiedTime: formatTime(f.ModifiedTime),
+	}
+	return json.Marshal(&fj)
+}
+
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	fj := filterJSON{}
+	if err := json.Unmarshal(data, &fj); err != nil {
+		return err
+	}
+	f.DecodeRule(fj.Rule)
+	f.Id = fj.Id
+	f.Name = fj.Name
+	f.SortOrder = fj.SortOrder
+	f.SortType = fj.SortType
+	f.ViewMode = fj.ViewMode
+	f.Etag = fj.Etag
+	f.CreatedTime = parseTime(fj.CreatedTime)
+	f.ModifiedTime = parseTime(fj.ModifiedTime)
+	return nil
+}
+
+// Env holds what evaluating a rule needs beyond the task itself.
+type Env struct {
+	// Now is the time due dates are compared with, time.Now() if zero.
+	Now time.Time
+	// WeekStart is the first day of the week for thisweek and nextweek.
+	WeekStart time.Weekday
+	// ProjectGroup returns the group id of the project with the given id,
+	// for group conditions.
+	ProjectGroup func(projectID string) string
+	// ProjectName returns the name of the project with the given id, for
+	// sorting by project.
+	ProjectName func(projectID string) string
+}
+
+func (r *Rule) Match(t *tasks.Task, env *Env) bool {
+	if env == nil {
+		env = &Env{}
+	}
+	now := env.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, c := range r.And {
+		if !c.match(t, env, now) {
+			return false
+		}
+	}
+	if len(r.Or) == 0 {
+		return true
+	}
+	for _, c := range r.Or {
+		if c.match(t, env, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether t has any of the Or values, all of the And values
+// and none of the Not values. Unknown conditions never match.
+func (c *Condition) match(t *tasks.Task, env *Env, now time.Time) bool {
+	has := func(v string) bool {
+		return c.matchValue(t, v, env, now)
+	}
+	if len(c.Or) > 0 && !anyValue(c.Or, has) {
+		return false
+	}
+	for _, v := range c.And {
+		if !has(v) {
+			return false
+		}
+	}
+	return !anyValue(c.Not, has)
+}
+
+func anyValue(vs Values, f func(string) bool) bool {
+	for _, v := range vs {
+		if f(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Condition) matchValue(t *tasks.Task, v string, env *Env, now time.Time) bool {
+	switch c.Name {
+	case ListCondition:
+		return t.ProjectId == v
+	case GroupCondition:
+		return env.ProjectGroup != nil && env.ProjectGroup(t.ProjectId) == v
+	case TagCondition:
+		if v == NO_TAGS {
+			return len(t.Tags) == 0
+		}
+		for _, tag := range t.Tags {
+			if strings.EqualFold(tag, v) {
+				return true
+			}
+		}
+		return false
+	case PriorityCondition:
+		p, err := strconv.Atoi(v)
+		return err == nil && int(t.Priority) == p
+	case DueDateCondition:
+		return matchDue(t, v, env.WeekStart, now)
+	case KeywordsCondition:
+		v = strings.ToLower(v)
+		return strings.Contains(strings.ToLower(t.Title), v) || strings.Contains(strings.ToLower(t.Content), v) ||
+			strings.Contains(strings.ToLower(t.Desc), v)
+	case TaskTypeCondition:
+		return (v == "note") == (t.Kind == tasks.Note)
+	}
+	return false
+}
+
+func dayOf(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// matchDue matches the due date values of the app: nodue, overdue, today,
+// tomorrow, thisweek, nextweek, thismonth, nextmonth, recurring, Ndays for
+// the next N days and Ndayslater for N or more days from now.
+func matchDue(t *tasks.Task, v string, weekStart time.Weekday, now time.Time) bool {
+	if v == "nodue" {
+		return t.DueDate.IsZero()
+	}
+	if v == "recurring" {
+		return t.RepeatFlag != ""
+	}
+	if t.DueDate.IsZero() {
+		return false
+	}
+	today, due := dayOf(now), dayOf(t.DueDate)
+	within := func(start, end time.Time) bool {
+		return !due.Before(start) && due.Before(end)
+	}
+	week := today.AddDate(0, 0, -((int(today.Weekday()) - int(weekStart) + 7) % 7))
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+	switch v {
+	case "overdue":
+		return t.DueDate.Before(now) && t.Status != tasks.Completed
+	case "today":
+		return due.Equal(today)
+	case "tomorrow":
+		return due.Equal(today.AddDate(0, 0, 1))
+	case "thisweek":
+		return within(week, week.AddDate(0, 0, 7))
+	case "nextweek":
+		return within(week.AddDate(0, 0, 7), week.AddDate(0, 0, 14))
+	case "thismonth":
+		return within(month, month.AddDate(0, 1, 0))
+	case "nextmonth":
+		return within(month.AddDate(0, 1, 0), month.AddDate(0, 2, 0))
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(v, "dayslater")); err == nil && strings.HasSuffix(v, "dayslater") {
+		return !due.Before(today.AddDate(0, 0, n))
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(v, "days")); err == nil && strings.HasSuffix(v, "days") {
+		return within(today, today.AddDate(0, 0, n))
+	}
+	return false
+}
+
+// sortKeys maps the filter sort types to query sort keys.
+var sortKeys = map[string][]query.SortKey{
+	"dueDate":   {{Field: "due"}},
+	"priority":  {{Field: "priority", Desc: true}},
+	"title":     {{Field: "title"}},
+	"project":   {{Field: "project"}, {Field: "order"}},
+	"sortOrder": {{Field: "order"}},
+}
+
+// Apply returns the tasks in ts matching the filter's rule, sorted by its
+// sort type. Tasks keep their order in ts for unknown sort types. Filters
+// with an undecoded RawRule match no tasks.
+func (f *Filter) Apply(ts []tasks.Task, env *Env) []tasks.Task {
+	if f.RawRule != "" {
+		return nil
+	}
+	if env == nil {
+		env = &Env{}
+	}
+	var res []tasks.Task
+	for i := range ts {
+		if f.Rule.Match(&ts[i], env) {
+			res = append(res, ts[i])
+		}
+	}
+	q := &query.Query{Sort: sortKeys[f.SortType]}
+	return q.Apply(res, &query.Env{Now: env.Now, ProjectName: env.ProjectName})
+}
@@ -0,0 +1,250 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/herzs11/go-ticktick/api/v1/types/tasks"
+)
+
+const filterJSONSample = `{
+	"id": "6761ad6f8f08b4f9b9e0d1a2",
+	"name": "Urgent this week",
+	"rule": "{\"and\":[{\"or\":[\"p1\",\"p2\"],\"conditionType\":1,\"conditionName\":\"list\"},{\"or\":[5,3],\"conditionType\":1,\"conditionName\":\"priority\"},{\"or\":[\"thisweek\",\"overdue\"],\"conditionType\":1,\"conditionName\":\"dueDate\"}],\"type\":0,\"version\":1}",
+	"sortOrder": -1099511627776,
+	"sortType": "dueDate",
+	"viewMode": "list",
+	"etag": "k3ub7t2x",
+	"createdTime": "2024-12-17T16:45:03.000+0000",
+	"modifiedTime": "2024-12-18T09:12:44.000+0000"
+}`
+
+func TestFilterJSON(t *testing.T) {
+	var f Filter
+	if err := json.Unmarshal([]byte(filterJSONSample), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "Urgent this week" || f.SortType != "dueDate" || f.Etag != "k3ub7t2x" {
+		t.Fatalf("Unexpected filter %+v", f)
+	}
+	if !f.ModifiedTime.Equal(time.Date(2024, 12, 18, 9, 12, 44, 0, time.UTC)) {
+		t.Errorf("Expected modified time 2024-12-18T09:12:44Z, got %s", f.ModifiedTime)
+	}
+	if len(f.Rule.And) != 3 || f.Rule.Version != 1 {
+		t.Fatalf("Unexpected rule %+v", f.Rule)
+	}
+	if p := f.Rule.And[1]; p.Name != PriorityCondition || len(p.Or) != 2 || p.Or[0] != "5" {
+		t.Errorf("Expected priorities decoded as strings, got %+v", p)
+	}
+
+	data, err := json.Marshal(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["rule"].(string); !ok {
+		t.Fatalf("Expected the rule encoded as a string, got %T", raw["rule"])
+	}
+	var again Filter
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Rule.And) != 3 || again.Rule.And[2].Or[1] != "overdue" || !again.CreatedTime.Equal(f.CreatedTime) {
+		t.Errorf("Expected the filter to round trip, got %+v", again)
+	}
+}
+
+func TestFilterRawRule(t *testing.T) {
+	rule := `{"and":[{"or":[{"offset":1}],"conditionType":1,"conditionName":"dueDate"}],"type":1}`
+	data, _ := json.Marshal(map[string]string{"id": "f1", "name": "Offset", "rule": rule})
+	var f Filter
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.RawRule != rule || len(f.Rule.And) != 0 {
+		t.Fatalf("Expected the rule kept raw, got %+v", f)
+	}
+	if got := f.Apply([]tasks.Task{{Id: "t1"}}, nil); len(got) != 0 {
+		t.Errorf("Expected a raw rule to match no tasks, got %+v", got)
+	}
+
+	data, err := json.Marshal(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]interface{}
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent["rule"] != rule {
+		t.Errorf("Expected the raw rule sent back unchanged, got %v", sent["rule"])
+	}
+
+	f.DecodeRule(`{"and":[{"or":["home"],"conditionName":"tag"}],"type":0}`)
+	if f.RawRule != "" || len(f.Rule.And) != 1 {
+		t.Errorf("Expected a decodable rule to clear the raw rule, got %+v", f)
+	}
+}
+
+func TestValuesJSON(t *testing.T) {
+	testCases := []struct {
+		json    string
+		want    Values
+		wantErr bool
+	}{
+		{`["p1", "p2"]`, Values{"p1", "p2"}, false},
+		{`[5, 0]`, Values{"5", "0"}, false},
+		{`[]`, Values{}, false},
+		{`[true]`, nil, true},
+		{`["p1", {"id": "p2"}]`, nil, true},
+		{`[null]`, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.json, func(t *testing.T) {
+				var v Values
+				err := json.Unmarshal([]byte(tc.json), &v)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("Expected error to be %t, got %v", tc.wantErr, err)
+				}
+				if !tc.wantErr && !reflect.DeepEqual(v, tc.want) {
+					t.Errorf("Expected %v, got %v", tc.want, v)
+				}
+			},
+		)
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	// Sunday, with weeks starting on Monday.
+	now := time.Date(2024, 12, 15, 12, 0, 0, 0, time.Local)
+	ts := []tasks.Task{
+		{
+			Id: "t1", ProjectId: "p1", Title: "Write report", Tags: []string{"Urgent"}, Priority: tasks.High,
+			DueDate: now.AddDate(0, 0, 1),
+		},
+		{Id: "t2", ProjectId: "p1", Title: "Review slides", Priority: tasks.Medium, DueDate: now.AddDate(0, 0, 5)},
+		{Id: "t3", ProjectId: "p2", Title: "Buy milk", Tags: []string{"errands"}, DueDate: now.AddDate(0, 0, -1)},
+		{Id: "t4", ProjectId: "p3", Title: "Ideas", Kind: tasks.Note},
+		{Id: "t5", ProjectId: "p2", Title: "Water plants", RepeatFlag: "RRULE:FREQ=DAILY", DueDate: now},
+	}
+	env := &Env{
+		Now:       now,
+		WeekStart: time.Monday,
+		ProjectGroup: func(id string) string {
+			return map[string]string{"p1": "g1", "p2": "g1"}[id]
+		},
+	}
+	cond := func(name string, or ...string) Condition {
+		return Condition{Name: name, Or: or}
+	}
+
+	testCases := []struct {
+		name string
+		rule Rule
+		want []string
+	}{
+		{"Empty", Rule{}, []string{"t1", "t2", "t3", "t4", "t5"}},
+		{"Lists", Rule{And: []Condition{cond(ListCondition, "p2", "p3")}}, []string{"t3", "t4", "t5"}},
+		{"Group", Rule{And: []Condition{cond(GroupCondition, "g1")}}, []string{"t1", "t2", "t3", "t5"}},
+		{"Tag", Rule{And: []Condition{cond(TagCondition, "urgent")}}, []string{"t1"}},
+		{"No tags", Rule{And: []Condition{cond(TagCondition, NO_TAGS)}}, []string{"t2", "t4", "t5"}},
+		{"Priority", Rule{And: []Condition{cond(PriorityCondition, "5", "3")}}, []string{"t1", "t2"}},
+		{"No due date", Rule{And: []Condition{cond(DueDateCondition, "nodue")}}, []string{"t4"}},
+		{"Overdue", Rule{And: []Condition{cond(DueDateCondition, "overdue")}}, []string{"t3"}},
+		{"Today", Rule{And: []Condition{cond(DueDateCondition, "today")}}, []string{"t5"}},
+		{"Tomorrow", Rule{And: []Condition{cond(DueDateCondition, "tomorrow")}}, []string{"t1"}},
+		{"This week", Rule{And: []Condition{cond(DueDateCondition, "thisweek")}}, []string{"t3", "t5"}},
+		{"Next week", Rule{And: []Condition{cond(DueDateCondition, "nextweek")}}, []string{"t1", "t2"}},
+		{"Next days", Rule{And: []Condition{cond(DueDateCondition, "3days")}}, []string{"t1", "t5"}},
+		{"Later", Rule{And: []Condition{cond(DueDateCondition, "5dayslater")}}, []string{"t2"}},
+		{"Recurring", Rule{And: []Condition{cond(DueDateCondition, "recurring")}}, []string{"t5"}},
+		{"Keywords", Rule{And: []Condition{cond(KeywordsCondition, "REPORT")}}, []string{"t1"}},
+		{"Notes", Rule{And: []Condition{cond(TaskTypeCondition, "note")}}, []string{"t4"}},
+		{"Unknown", Rule{And: []Condition{cond("assignee", "me")}}, nil},
+		{
+			"Not",
+			Rule{And: []Condition{{Name: ListCondition, Not: Values{"p1"}}}},
+			[]string{"t3", "t4", "t5"},
+		},
+		{
+			"All tags",
+			Rule{And: []Condition{{Name: TagCondition, And: Values{"urgent", "errands"}}}},
+			nil,
+		},
+		{
+			"And",
+			Rule{And: []Condition{cond(ListCondition, "p1"), cond(PriorityCondition, "3")}},
+			[]string{"t2"},
+		},
+		{
+			"Or",
+			Rule{Type: AdvancedRule, Or: []Condition{cond(TagCondition, "errands"), cond(TaskTypeCondition, "note")}},
+			[]string{"t3", "t4"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				var got []string
+				for i := range ts {
+					if tc.rule.Match(&ts[i], env) {
+						got = append(got, ts[i].Id)
+					}
+				}
+				if len(got) != len(tc.want) {
+					t.Fatalf("Expected %v, got %v", tc.want, got)
+				}
+				for i := range got {
+					if got[i] != tc.want[i] {
+						t.Fatalf("Expected %v, got %v", tc.want, got)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestFilterApply(t *testing.T) {
+	now := time.Date(2024, 12, 15, 12, 0, 0, 0, time.Local)
+	ts := []tasks.Task{
+		{Id: "t1", ProjectId: "p1", Title: "B", Priority: tasks.Low, DueDate: now.AddDate(0, 0, 3), SortOrder: 2},
+		{Id: "t2", ProjectId: "p1", Title: "A", Priority: tasks.High, SortOrder: 1},
+		{Id: "t3", ProjectId: "p1", Title: "C", Priority: tasks.Medium, DueDate: now.AddDate(0, 0, 1), SortOrder: 3},
+		{Id: "t4", ProjectId: "p2", Title: "D"},
+	}
+	testCases := []struct {
+		sortType string
+		want     []string
+	}{
+		{"dueDate", []string{"t3", "t1", "t2"}},
+		{"priority", []string{"t2", "t3", "t1"}},
+		{"title", []string{"t2", "t1", "t3"}},
+		{"sortOrder", []string{"t2", "t1", "t3"}},
+		{"", []string{"t1", "t2", "t3"}},
+	}
+	for _, tc := range testCases {
+		f := &Filter{
+			Rule:     Rule{And: []Condition{{Name: ListCondition, Or: Values{"p1"}}}},
+			SortType: tc.sortType,
+		}
+		var got []string
+		for _, task := range f.Apply(ts, &Env{Now: now}) {
+			got = append(got, task.Id)
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("%q: Expected %v, got %v", tc.sortType, tc.want, got)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%q: Expected %v, got %v", tc.sortType, tc.want, got)
+			}
+		}
+	}
+}
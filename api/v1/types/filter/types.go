@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"time"
+)
+
+// TIME_FORMAT is the format of the times TickTick sends with filters.
+const TIME_FORMAT = "2006-01-02T15:04:05.000+0000"
+
+// Rule types. Normal rules are built with the app's simple filter editor;
+// advanced ones may combine conditions with or.
+const (
+	NormalRule   = 0
+	AdvancedRule = 1
+)
+
+// Condition names known to TickTick's filter editor.
+const (
+	ListCondition     = "list"
+	GroupCondition    = "group"
+	TagCondition      = "tag"
+	PriorityCondition = "priority"
+	DueDateCondition  = "dueDate"
+	KeywordsCondition = "keywords"
+	TaskTypeCondition = "taskType"
+)
+
+// NO_TAGS is the tag condition value matching tasks without tags.
+const NO_TAGS = "!tag"
+
+// Values holds condition values, which TickTick sends as strings or, for
+// priorities, numbers. Values of other types fail to decode; a filter whose
+// rule has them keeps the rule in RawRule instead.
+type Values []string
+
+type Condition struct {
+	Name string `json:"conditionName"`
+	Type int    `json:"conditionType"`
+	Or   Values `json:"or,omitempty"`
+	And  Values `json:"and,omitempty"`
+	Not  Values `json:"not,omitempty"`
+}
+
+// Rule is the decoded rule of a filter. Every condition in And must match,
+// and at least one in Or if it has any.
+type Rule struct {
+	Type    int         `json:"type"`
+	Version int         `json:"version,omitempty"`
+	And     []Condition `json:"and,omitempty"`
+	Or      []Condition `json:"or,omitempty"`
+}
+
+type filterJSON struct {
+	Id           string `json:"id,omitempty"`
+	Name         string `json:"name"`
+	Rule         string `json:"rule"`
+	SortOrder    int64  `json:"sortOrder"`
+	SortType     string `json:"sortType,omitempty"`
+	ViewMode     string `json:"viewMode,omitempty"`
+	Etag         string `json:"etag,omitempty"`
+	CreatedTime  string `json:"createdTime,omitempty"`
+	ModifiedTime string `json:"modifiedTime,omitempty"`
+}
+
+// Filter is a saved filter, shown as a smart list in the app.
+type Filter struct {
+	Id           string
+	Name         string
+	Rule         Rule
+	SortOrder    int64
+	SortType     string
+	ViewMode     string
+	Etag         string
+	CreatedTime  time.Time
+	ModifiedTime time.Time
+	// RawRule is the rule as TickTick sent it when it could not be decoded
+	// into Rule. It is sent back unchanged, and the filter matches no tasks.
+	RawRule string
+}
@@ -102,6 +102,8 @@ func (po *Project) MarshalJSON() ([]byte, error) {
 		Color:    po.Color,
 		ViewMode: po.ViewMode.String(),
 		Kind:     po.Kind.String(),
+		GroupId:  po.GroupId,
+		Closed:   po.Closed,
 		Tasks:    po.Tasks,
 	}
 	return json.Marshal(m)
@@ -0,0 +1,25 @@
+package tag
+
+import (
+	"strings"
+)
+
+// Tag is an account-wide tag. Name is the lowercase key tasks refer to the
+// tag by, and Label the name as the user typed it.
+type Tag struct {
+	Name      string `json:"name"`
+	RawName   string `json:"rawName,omitempty"`
+	Label     string `json:"label"`
+	Color     string `json:"color,omitempty"`
+	Parent    string `json:"parent,omitempty"`
+	SortOrder int64  `json:"sortOrder"`
+	SortType  string `json:"sortType,omitempty"`
+	ViewMode  string `json:"viewMode,omitempty"`
+	Type      int    `json:"type,omitempty"`
+	Etag      string `json:"etag,omitempty"`
+}
+
+// NameOf returns the name tasks use for the tag with the given label.
+func NameOf(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
@@ -41,6 +41,41 @@ func (c *ChecklistItem) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (r *reminderList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.triggers = make([]string, 0, len(raw))
+	for _, item := range raw {
+		var trigger string
+		if err := json.Unmarshal(item, &trigger); err != nil {
+			var obj reminderObject
+			if err := json.Unmarshal(item, &obj); err != nil {
+				return err
+			}
+			if r.ids == nil {
+				r.ids = map[string]string{}
+			}
+			trigger = obj.Trigger
+			r.ids[trigger] = obj.Id
+		}
+		r.triggers = append(r.triggers, trigger)
+	}
+	return nil
+}
+
+func (r *reminderList) MarshalJSON() ([]byte, error) {
+	if r.ids == nil {
+		return json.Marshal(r.triggers)
+	}
+	objs := make([]reminderObject, len(r.triggers))
+	for i, trigger := range r.triggers {
+		objs[i] = reminderObject{Id: r.ids[trigger], Trigger: trigger}
+	}
+	return json.Marshal(objs)
+}
+
 func (t *Task) toJSON() taskJSON {
 	tj := taskJSON{
 		Id:             t.Id,
@@ -53,12 +88,22 @@ func (t *Task) toJSON() taskJSON {
 		DueDate:        convertLocalTime(t.DueDate),
 		ChecklistItems: t.ChecklistItems,
 		Priority:       int(t.Priority),
-		Reminders:      t.Reminders,
+		Tags:           t.Tags,
 		RepeatFlag:     t.RepeatFlag,
 		SortOrder:      t.SortOrder,
 		StartDate:      convertLocalTime(t.StartDate),
-		Status:         int(t.Status),
+		Status:         statusToInt(t.Status),
 		TimeZone:       t.TimeZone,
+		Kind:           t.Kind.String(),
+		ParentId:       t.ParentId,
+		Etag:           t.Etag,
+		ModifiedTime:   convertLocalTime(t.ModifiedTime),
+	}
+	if t.Kind == Text && len(t.ChecklistItems) > 0 {
+		tj.Kind = Checklist.String()
+	}
+	if len(t.Reminders) > 0 {
+		tj.Reminders = &reminderList{triggers: t.Reminders, ids: t.ReminderIds}
 	}
 	return tj
 }
@@ -85,15 +130,57 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	t.DueDate = convertUTCString(tj.DueDate)
 	t.ChecklistItems = tj.ChecklistItems
 	t.Priority = Priority(tj.Priority)
-	t.Reminders = tj.Reminders
+	t.Reminders, t.ReminderIds = nil, nil
+	if tj.Reminders != nil {
+		t.Reminders, t.ReminderIds = tj.Reminders.triggers, tj.Reminders.ids
+	}
+	t.Tags = tj.Tags
 	t.RepeatFlag = tj.RepeatFlag
 	t.SortOrder = tj.SortOrder
 	t.StartDate = convertUTCString(tj.StartDate)
-	if tj.Status == 2 {
-		t.Status = Completed
-	} else {
-		t.Status = Normal
-	}
+	t.Status = statusFromInt(tj.Status)
+	t.TimeZone = tj.TimeZone
+	t.Kind = kindFromString(tj.Kind)
+	t.ParentId = tj.ParentId
+	t.Etag = tj.Etag
+	t.ModifiedTime = convertUTCString(tj.ModifiedTime)
 
 	return nil
 }
+
+// Clone returns a copy of the task that shares no slices or maps with the
+// original.
+func (t *Task) Clone() Task {
+	c := *t
+	c.ChecklistItems = append([]ChecklistItem(nil), t.ChecklistItems...)
+	c.Reminders = append([]string(nil), t.Reminders...)
+	if t.ReminderIds != nil {
+		c.ReminderIds = make(map[string]string, len(t.ReminderIds))
+		for trigger, id := range t.ReminderIds {
+			c.ReminderIds[trigger] = id
+		}
+	}
+	c.Tags = append([]string(nil), t.Tags...)
+	return c
+}
+
+func (t *Task) IsNote() bool {
+	return t.Kind == Note
+}
+
+// Markdown returns the Markdown body of a text task or note. For checklist
+// tasks the text shown above the items is held in Desc instead.
+func (t *Task) Markdown() string {
+	if t.Kind == Checklist {
+		return t.Desc
+	}
+	return t.Content
+}
+
+func (t *Task) SetMarkdown(md string) {
+	if t.Kind == Checklist {
+		t.Desc = md
+		return
+	}
+	t.Content = md
+}
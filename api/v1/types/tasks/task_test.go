@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 )
@@ -80,3 +81,140 @@ func TestConvertLocalTime(t *testing.T) {
 		)
 	}
 }
+
+func TestTaskStatusJSON(t *testing.T) {
+	for _, status := range []Status{Normal, Completed, WontDo} {
+		in := Task{Id: "1", Title: "task", Status: status}
+		data, err := json.Marshal(&in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Task
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Status != status {
+			t.Errorf("Status %s did not survive a round trip, got %s", status, out.Status)
+		}
+	}
+
+	data, err := json.Marshal(&Task{Title: "task", Status: Completed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw["status"] != float64(2) {
+		t.Errorf("Expected completed status to marshal as 2, got %v", raw["status"])
+	}
+}
+
+func TestTaskKindJSON(t *testing.T) {
+	testCases := []struct {
+		name string
+		task Task
+		want string
+	}{
+		{"Text task", Task{Title: "task"}, "TEXT"},
+		{"Text task with items", Task{Title: "task", ChecklistItems: []ChecklistItem{{Title: "item"}}}, "CHECKLIST"},
+		{"Checklist task", Task{Title: "task", Kind: Checklist}, "CHECKLIST"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				tj := tc.task.toJSON()
+				if tj.Kind != tc.want {
+					t.Errorf("toJSON() kind = %q, want %q", tj.Kind, tc.want)
+				}
+			},
+		)
+	}
+}
+
+func TestTaskMarkdown(t *testing.T) {
+	note := Task{Title: "note", Kind: Note}
+	note.SetMarkdown("# Heading")
+	if note.Content != "# Heading" || note.Markdown() != "# Heading" {
+		t.Errorf("Expected note markdown in Content, got content %q desc %q", note.Content, note.Desc)
+	}
+
+	checklist := Task{Title: "checklist", Kind: Checklist}
+	checklist.SetMarkdown("*details*")
+	if checklist.Desc != "*details*" || checklist.Markdown() != "*details*" {
+		t.Errorf("Expected checklist markdown in Desc, got content %q desc %q", checklist.Content, checklist.Desc)
+	}
+
+	var round Task
+	data, err := json.Marshal(&note)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &round); err != nil {
+		t.Fatal(err)
+	}
+	if !round.IsNote() {
+		t.Errorf("Expected kind NOTE after round trip, got %s", round.Kind)
+	}
+}
+
+func TestTaskVersionJSON(t *testing.T) {
+	var task Task
+	data := `{"id": "t1", "etag": "wjuq5lv2", "modifiedTime": "2024-12-07T05:53:21.000+0000"}`
+	if err := json.Unmarshal([]byte(data), &task); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 12, 7, 5, 53, 21, 0, time.UTC)
+	if task.Etag != "wjuq5lv2" || !task.ModifiedTime.Equal(want) {
+		t.Errorf("Expected etag and modified time to be decoded, got %q %s", task.Etag, task.ModifiedTime)
+	}
+	if tj := task.toJSON(); tj.Etag != "wjuq5lv2" || tj.ModifiedTime != "2024-12-07T05:53:21.000+0000" {
+		t.Errorf("Expected etag and modified time to be encoded, got %q %q", tj.Etag, tj.ModifiedTime)
+	}
+}
+
+func TestTaskRemindersJSON(t *testing.T) {
+	testCases := []struct {
+		name string
+		json string
+		// want is the reminders written back after adding TRIGGER:-PT1H.
+		want string
+	}{
+		{
+			"Open API", `{"reminders": ["TRIGGER:-PT10M", "TRIGGER:PT0S"]}`,
+			`["TRIGGER:-PT10M","TRIGGER:PT0S","TRIGGER:-PT1H"]`,
+		},
+		{
+			"Web API", `{"reminders": [{"id": "r1", "trigger": "TRIGGER:-PT10M"}, {"id": "r2", "trigger": "TRIGGER:PT0S"}]}`,
+			`[{"id":"r1","trigger":"TRIGGER:-PT10M"},{"id":"r2","trigger":"TRIGGER:PT0S"},{"trigger":"TRIGGER:-PT1H"}]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				var task Task
+				if err := json.Unmarshal([]byte(tc.json), &task); err != nil {
+					t.Fatal(err)
+				}
+				if len(task.Reminders) != 2 || task.Reminders[0] != "TRIGGER:-PT10M" || task.Reminders[1] != "TRIGGER:PT0S" {
+					t.Errorf("Expected both triggers, got %v", task.Reminders)
+				}
+				task = task.Clone()
+				task.Reminders = append(task.Reminders, "TRIGGER:-PT1H")
+				data, err := json.Marshal(&task)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var written struct {
+					Reminders json.RawMessage `json:"reminders"`
+				}
+				json.Unmarshal(data, &written)
+				if string(written.Reminders) != tc.want {
+					t.Errorf("Expected reminders %s, got %s", tc.want, written.Reminders)
+				}
+			},
+		)
+	}
+}
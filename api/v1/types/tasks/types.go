@@ -8,6 +8,7 @@ const TASK_ENDPOINT = "/open/v1/task"
 
 type Priority int
 type Status int
+type Kind int
 
 const (
 	None   Priority = 0
@@ -19,14 +20,49 @@ const (
 const (
 	Normal Status = iota
 	Completed
+	// WontDo is the status of tasks closed without being done, which only the
+	// v2 API exposes.
+	WontDo Status = -1
 )
 
+const (
+	Text Kind = iota
+	Checklist
+	Note
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Text:
+		return "TEXT"
+	case Checklist:
+		return "CHECKLIST"
+	case Note:
+		return "NOTE"
+	default:
+		return ""
+	}
+}
+
+func kindFromString(s string) Kind {
+	switch s {
+	case "CHECKLIST":
+		return Checklist
+	case "NOTE":
+		return Note
+	default:
+		return Text
+	}
+}
+
 func (s Status) String() string {
 	switch s {
 	case Normal:
 		return "Normal"
 	case Completed:
 		return "Completed"
+	case WontDo:
+		return "Won't do"
 	default:
 		return ""
 	}
@@ -69,6 +105,20 @@ type ChecklistItem struct {
 	TimeZone      string
 }
 
+// reminderList holds reminder triggers such as TRIGGER:-PT10M. The open API
+// sends them as strings and the web API as objects with an id and a trigger,
+// in which case ids maps each trigger to its id so they are written back in
+// the same form.
+type reminderList struct {
+	triggers []string
+	ids      map[string]string
+}
+
+type reminderObject struct {
+	Id      string `json:"id,omitempty"`
+	Trigger string `json:"trigger"`
+}
+
 type taskJSON struct {
 	Id             string          `json:"id,omitempty"`
 	ProjectId      string          `json:"projectId,omitempty"`
@@ -80,13 +130,17 @@ type taskJSON struct {
 	DueDate        string          `json:"dueDate,omitempty"`
 	ChecklistItems []ChecklistItem `json:"items,omitempty"`
 	Priority       int             `json:"priority,omitempty"`
-	Reminders      []string        `json:"reminders,omitempty"`
+	Reminders      *reminderList   `json:"reminders,omitempty"`
 	Tags           []string        `json:"tags,omitempty"`
 	RepeatFlag     string          `json:"repeatFlag,omitempty"`
 	SortOrder      int64           `json:"sortOrder,omitempty"`
 	StartDate      string          `json:"startDate,omitempty"`
 	Status         int             `json:"status,omitempty"`
 	TimeZone       string          `json:"timeZone,omitempty"`
+	Kind           string          `json:"kind,omitempty"`
+	ParentId       string          `json:"parentId,omitempty"`
+	Etag           string          `json:"etag,omitempty"`
+	ModifiedTime   string          `json:"modifiedTime,omitempty"`
 }
 
 type Task struct {
@@ -101,9 +155,19 @@ type Task struct {
 	ChecklistItems []ChecklistItem
 	Priority       Priority
 	Reminders      []string
-	RepeatFlag     string
-	SortOrder      int64
-	StartDate      time.Time
-	Status         Status
-	TimeZone       string
+	// ReminderIds maps the triggers in Reminders to the ids the web API gave
+	// them. It is nil for tasks read from the open API.
+	ReminderIds map[string]string
+	Tags        []string
+	RepeatFlag  string
+	SortOrder   int64
+	StartDate   time.Time
+	Status      Status
+	TimeZone    string
+	Kind        Kind
+	ParentId    string
+	// Etag and ModifiedTime identify the server version the task was read
+	// from. They are set by the server and used to detect conflicting edits.
+	Etag         string
+	ModifiedTime time.Time
 }
@@ -20,3 +20,23 @@ func convertLocalTime(t time.Time) string {
 	}
 	return t.UTC().Format(TIME_FORMAT)
 }
+
+func statusToInt(s Status) int {
+	switch s {
+	case Completed:
+		return 2
+	case WontDo:
+		return -1
+	}
+	return 0
+}
+
+func statusFromInt(i int) Status {
+	switch i {
+	case 2:
+		return Completed
+	case -1:
+		return WontDo
+	}
+	return Normal
+}
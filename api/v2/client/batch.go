@@ -0,0 +1,78 @@
+package client
+
+import (
+	`bytes`
+	`encoding/json`
+	`net/http`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+const BATCH_SIZE = 50
+
+type TaskRef struct {
+	TaskId    string `json:"taskId"`
+	ProjectId string `json:"projectId"`
+}
+
+type batchTaskParams struct {
+	Add    []*tasks.Task `json:"add"`
+	Update []*tasks.Task `json:"update"`
+	Delete []TaskRef     `json:"delete"`
+}
+
+type BatchResponse struct {
+	Id2Etag  map[string]string `json:"id2etag"`
+	Id2Error map[string]string `json:"id2error"`
+}
+
+// BatchTasks sends adds, updates and deletes to the batch/task endpoint in a
+// single request. Tasks in add without an id are given a generated one so
+// their result can be found in the response.
+func (c *Client) BatchTasks(add, update []*tasks.Task, del []TaskRef) (*BatchResponse, error) {
+	for _, t := range add {
+		if t.Id == "" {
+			t.Id = newObjectId()
+		}
+	}
+	params := batchTaskParams{
+		Add:    add,
+		Update: update,
+		Delete: del,
+	}
+	if params.Add == nil {
+		params.Add = []*tasks.Task{}
+	}
+	if params.Update == nil {
+		params.Update = []*tasks.Task{}
+	}
+	if params.Delete == nil {
+		params.Delete = []TaskRef{}
+	}
+	return c.postBatch("batch/task", params)
+}
+
+// postBatch posts params to a batch endpoint and decodes the per-object
+// results.
+func (c *Client) postBatch(path string, params interface{}) (*BatchResponse, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", BASE_URL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var br BatchResponse
+	err = json.NewDecoder(resp.Body).Decode(&br)
+	if err != nil {
+		return nil, err
+	}
+	return &br, nil
+}
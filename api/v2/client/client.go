@@ -2,19 +2,28 @@ package client
 
 import (
 	`bytes`
+	`context`
 	`encoding/json`
+	`errors`
 	`fmt`
-	`io`
 	`net/http`
 	`net/http/cookiejar`
-	`os`
+	`sync`
+	`time`
 	
+	`github.com/herzs11/go-ticktick/api/backend`
+	`github.com/herzs11/go-ticktick/api/tokenstore`
 	`github.com/valyala/fasttemplate`
 )
 
 const (
 	USER_AGENT            = "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:129.0) Gecko/20100101 Firefox/129.0"
 	BASE_URL              = "https://api.ticktick.com/api/v2/"
+	// DEFAULT_TIMEOUT bounds each request made with the default HTTP client.
+	DEFAULT_TIMEOUT       = 30 * time.Second
+	// FULL_SYNC_TTL is how long the account state downloaded by one of the
+	// getters is reused by the others.
+	FULL_SYNC_TTL         = 10 * time.Second
 	X_DEVICE_TEMPLATE_STR = `{"platform":"web","os":"OS X","device":"Firefox 95.0","name":"go-ticktick", "version":4531,"id":"6491[token]","channel":"website","campaign":"","websocket":""}`
 )
 
@@ -26,10 +35,81 @@ type Client struct {
 	username    string
 	password    string
 	AccessToken string
-	header      *http.Header
+	header      http.Header
 	userId      string
 	httpClient  *http.Client
 	InboxId     string
+	loggedIn    bool
+	twoFactor   TwoFactorFunc
+
+	sessionStore tokenstore.Store
+
+	fullSyncMu sync.Mutex
+	fullSync   *SyncResponse
+	fullSyncAt time.Time
+}
+
+var _ backend.Backend = (*Client)(nil)
+
+type StatusError struct {
+	StatusCode int
+	Path       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Request to %s returned status code %d", e.Path, e.StatusCode)
+}
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTwoFactorRequired  = errors.New("account requires two-factor authentication but no handler is set")
+)
+
+// invalidCredentialCodes are the error codes the sign on endpoints use for
+// wrong usernames, passwords or verification codes.
+var invalidCredentialCodes = map[string]bool{
+	"username_password_not_match":       true,
+	"incorrect_password_too_many_times": true,
+	"username_not_exist":                true,
+	"invalid_verify_code":               true,
+}
+
+// APIError is an error response from the API with TickTick's error code and
+// message, e.g. username_password_not_match.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Path       string `json:"-"`
+	Code       string `json:"errorCode"`
+	Message    string `json:"errorMessage"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("Request to %s returned status code %d", e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("Request to %s failed with %s: %s", e.Path, e.Code, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	return target == ErrInvalidCredentials && invalidCredentialCodes[e.Code]
+}
+
+// TwoFactorFunc is asked for the current verification code when signing in
+// to an account with two-factor authentication.
+type TwoFactorFunc func(ctx context.Context) (string, error)
+
+type ClientOption func(*Client)
+
+func WithTwoFactor(fn TwoFactorFunc) ClientOption {
+	return func(c *Client) {
+		c.twoFactor = fn
+	}
+}
+
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
 }
 
 type loginParams struct {
@@ -37,8 +117,13 @@ type loginParams struct {
 	Password string `json:"password"`
 }
 
-func NewClient(username, password string) *Client {
-	h := &http.Header{}
+type verifyParams struct {
+	Code   string `json:"code"`
+	Method string `json:"method"`
+}
+
+func NewClient(username, password string, opts ...ClientOption) *Client {
+	h := http.Header{}
 	h.Add("User-Agent", USER_AGENT)
 	randToken, _ := randomHex(10)
 	xDev := X_DEVICE_TEMPLATE.ExecuteString(
@@ -48,62 +133,137 @@ func NewClient(username, password string) *Client {
 	)
 	h.Add("x-device", xDev)
 	jar, _ := cookiejar.New(nil)
-	return &Client{
+	c := &Client{
 		username: username,
 		password: password,
 		header:   h,
 		httpClient: &http.Client{
-			Jar: jar,
+			Jar:     jar,
+			Timeout: DEFAULT_TIMEOUT,
 		},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func (c *Client) login() error {
-	u := BASE_URL + "user/signon"
+// Login signs in with the client's username and password, storing the
+// session token and inbox id for later requests. Accounts with two-factor
+// authentication need a handler set with WithTwoFactor, otherwise
+// ErrTwoFactorRequired is returned. Wrong credentials give an error matching
+// ErrInvalidCredentials.
+func (c *Client) Login(ctx context.Context) error {
 	lp := loginParams{Username: c.username, Password: c.password}
-	params, err := json.Marshal(lp)
+	var lr loginResponse
+	if err := c.postAuth(ctx, "user/signon?wc=true&remember=true", lp, nil, &lr); err != nil {
+		return err
+	}
+	if lr.Token == "" && lr.AuthId != "" {
+		if c.twoFactor == nil {
+			return ErrTwoFactorRequired
+		}
+		code, err := c.twoFactor(ctx)
+		if err != nil {
+			return err
+		}
+		authId := lr.AuthId
+		lr = loginResponse{}
+		vp := verifyParams{Code: code, Method: "app"}
+		err = c.postAuth(ctx, "user/sign/mfa/code/verify", vp, map[string]string{"x-verify-id": authId}, &lr)
+		if err != nil {
+			return err
+		}
+	}
+	if lr.Token == "" {
+		return errors.New("login response did not contain a token")
+	}
+	c.AccessToken = lr.Token
+	c.userId = lr.UserId
+	if lr.InboxId != "" {
+		c.InboxId = lr.InboxId
+	}
+	c.loggedIn = true
+	c.saveSession()
+	return nil
+}
+
+// postAuth posts params to one of the sign on endpoints and decodes the
+// response into out. Failures are returned as *APIError.
+func (c *Client) postAuth(
+	ctx context.Context, path string, params interface{}, header map[string]string, out interface{},
+) error {
+	data, err := json.Marshal(params)
 	if err != nil {
-		return fmt.Errorf("Failed to marshal login params: %s", err.Error())
+		return fmt.Errorf("failed to marshal login params: %w", err)
 	}
-	req, err := http.NewRequest("POST", u, bytes.NewBuffer(params))
+	req, err := http.NewRequestWithContext(ctx, "POST", BASE_URL+path, bytes.NewBuffer(data))
 	if err != nil {
-		return fmt.Errorf("Failed to create request object: %s", err.Error())
-	}
-	req.URL.Query().Add("wc", "true")
-	req.URL.Query().Add("remember", "true")
-	req.Header = *c.header
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Referer", "https://ticktick.com")
-	req.Header.Add("Origin", "https://ticktick.com")
+		return fmt.Errorf("failed to create request object: %w", err)
+	}
+	req.Header = c.header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://ticktick.com")
+	req.Header.Set("Origin", "https://ticktick.com")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
 	resp, err := c.httpClient.Do(req)
-	defer resp.Body.Close()
 	if err != nil {
-		return fmt.Errorf("Login request failed: %s", err.Error())
+		return fmt.Errorf("login request failed: %w", err)
 	}
-	var responseBody loginResponse
-	err = json.NewDecoder(resp.Body).Decode(&responseBody)
-	if err != nil {
-		return fmt.Errorf("Got unexpected response: %s", err)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Path: req.URL.Path}
+		json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("got unexpected response: %w", err)
 	}
-	
 	return nil
 }
 
-func (c *Client) getState() error {
-	u := BASE_URL + "batch/check/0"
-	req, _ := http.NewRequest("GET", u, nil)
-	req.Header = *c.header
-	
+func (c *Client) GetInboxId() (string, error) {
+	if c.InboxId == "" {
+		if err := c.EnsureSession(context.Background()); err != nil {
+			return "", err
+		}
+	}
+	if c.InboxId == "" {
+		if err := c.Login(context.Background()); err != nil {
+			return "", err
+		}
+	}
+	return c.InboxId, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.EnsureSession(req.Context()); err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Method != http.MethodGet {
+		c.forgetFullSync()
+	}
+	req.AddCookie(&http.Cookie{Name: "t", Value: c.AccessToken})
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
-	
-	file, _ := os.Create("testout.json")
-	defer file.Close()
-	
-	io.Copy(file, resp.Body)
-	
-	return nil
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			// The session expired, sign in again on the next request.
+			c.loggedIn = false
+			c.forgetSession()
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Path: req.URL.Path}
+	}
+	return resp, nil
 }
@@ -1,7 +1,7 @@
 package client
 
 import (
-	`log`
+	`context`
 	`os`
 	`testing`
 	
@@ -9,25 +9,21 @@ import (
 )
 
 func TestLogin(t *testing.T) {
-	err := godotenv.Load("../../../.env")
-	if err != nil {
-		log.Fatal(err)
+	if err := godotenv.Load("../../../.env"); err != nil {
+		t.Log("Could not load .env file")
 	}
 	ttPass := os.Getenv("TT_PASS")
-	if ttPass == "" {
-		log.Fatal("TT_PASS environment variable not set")
-	}
 	ttUser := os.Getenv("TT_USER")
-	if ttUser == "" {
-		log.Fatal("TT_USER environment variable not set")
+	if ttPass == "" || ttUser == "" {
+		t.Skip("TT_USER and TT_PASS must be set to log in to TickTick")
 	}
 	c := NewClient(ttUser, ttPass)
-	err = c.login()
+	err := c.Login(context.Background())
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
-	err = c.getState()
+	_, err = c.Sync(context.Background(), 0)
 	if err != nil {
-		log.Fatal(err)
+		t.Fatal(err)
 	}
 }
@@ -0,0 +1,75 @@
+package client
+
+import (
+	`context`
+	`errors`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/filter`
+)
+
+type batchFilterParams struct {
+	Add    []*filter.Filter `json:"add"`
+	Update []*filter.Filter `json:"update"`
+	Delete []string         `json:"delete"`
+}
+
+func (c *Client) batchFilters(params batchFilterParams) (*BatchResponse, error) {
+	if params.Add == nil {
+		params.Add = []*filter.Filter{}
+	}
+	if params.Update == nil {
+		params.Update = []*filter.Filter{}
+	}
+	if params.Delete == nil {
+		params.Delete = []string{}
+	}
+	return c.postBatch("batch/filter", params)
+}
+
+// GetFilters returns the saved filters of the account.
+func (c *Client) GetFilters() ([]*filter.Filter, error) {
+	sr, err := c.accountState(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	filters := make([]*filter.Filter, len(sr.Filters))
+	for i, f := range sr.Filters {
+		fc := *f
+		filters[i] = &fc
+	}
+	return filters, nil
+}
+
+func (c *Client) CreateFilter(f *filter.Filter) error {
+	if f.Name == "" {
+		return errors.New("Must provide a name for the filter")
+	}
+	if f.Id == "" {
+		f.Id = newObjectId()
+	}
+	br, err := c.batchFilters(batchFilterParams{Add: []*filter.Filter{f}})
+	if err != nil {
+		return err
+	}
+	f.Etag = br.Id2Etag[f.Id]
+	return br.errorFor(f.Id)
+}
+
+func (c *Client) UpdateFilter(f *filter.Filter) error {
+	br, err := c.batchFilters(batchFilterParams{Update: []*filter.Filter{f}})
+	if err != nil {
+		return err
+	}
+	if etag, ok := br.Id2Etag[f.Id]; ok {
+		f.Etag = etag
+	}
+	return br.errorFor(f.Id)
+}
+
+func (c *Client) DeleteFilter(id string) error {
+	br, err := c.batchFilters(batchFilterParams{Delete: []string{id}})
+	if err != nil {
+		return err
+	}
+	return br.errorFor(id)
+}
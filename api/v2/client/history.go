@@ -0,0 +1,150 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`iter`
+	`net/http`
+	`net/url`
+	`strconv`
+	`time`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+const (
+	HISTORY_TIME_FORMAT = "2006-01-02 15:04:05"
+	HISTORY_PAGE_SIZE   = 50
+	// HISTORY_MAX_PAGE_SIZE bounds the pages requested to get past tasks
+	// completed in the same second.
+	HISTORY_MAX_PAGE_SIZE = 1000
+)
+
+// ErrHistoryTruncated is returned by CompletedTasks when more than
+// HISTORY_MAX_PAGE_SIZE tasks were completed in the same second, so the rest
+// of them cannot be fetched.
+var ErrHistoryTruncated = errors.New("too many tasks completed in the same second to page through history")
+
+// HistoryQuery selects closed tasks for CompletedTasks.
+type HistoryQuery struct {
+	// ProjectId limits the history to one project, all projects if empty.
+	ProjectId string
+	// From and To bound the completion time, unbounded if zero.
+	From time.Time
+	To   time.Time
+	// Status is tasks.Completed or tasks.WontDo, tasks.Completed if zero.
+	Status tasks.Status
+	// PageSize is the number of tasks fetched per request,
+	// HISTORY_PAGE_SIZE if zero.
+	PageSize int
+}
+
+func (q HistoryQuery) url(to time.Time, limit int) string {
+	projectID := q.ProjectId
+	if projectID == "" {
+		projectID = "all"
+	}
+	status := "Completed"
+	if q.Status == tasks.WontDo {
+		status = "Abandoned"
+	}
+	v := url.Values{}
+	v.Set("status", status)
+	v.Set("limit", strconv.Itoa(limit))
+	if !q.From.IsZero() {
+		v.Set("from", q.From.Local().Format(HISTORY_TIME_FORMAT))
+	}
+	if !to.IsZero() {
+		v.Set("to", to.Local().Format(HISTORY_TIME_FORMAT))
+	}
+	return BASE_URL + "project/" + url.PathEscape(projectID) + "/closed?" + v.Encode()
+}
+
+func (q HistoryQuery) pageSize() int {
+	if q.PageSize <= 0 {
+		return HISTORY_PAGE_SIZE
+	}
+	return q.PageSize
+}
+
+func (c *Client) closedPage(ctx context.Context, u string) ([]tasks.Task, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var page []tasks.Task
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// CompletedTasks streams the tasks closed in the range of q, most recently
+// completed first, fetching further pages as the iteration goes on. The
+// server pages by completion time to the second, so each page ends where the
+// previous one stopped and tasks already seen are skipped. A full page
+// completed within one second is fetched again with a larger page size, and
+// ErrHistoryTruncated is returned if that exceeds HISTORY_MAX_PAGE_SIZE.
+// Iteration stops after the first error.
+func (c *Client) CompletedTasks(ctx context.Context, q HistoryQuery) iter.Seq2[tasks.Task, error] {
+	return func(yield func(tasks.Task, error) bool) {
+		to := q.To.Truncate(time.Second)
+		limit := q.pageSize()
+		seen := map[string]bool{}
+		for {
+			page, err := c.closedPage(ctx, q.url(to, limit))
+			if err != nil {
+				yield(tasks.Task{}, err)
+				return
+			}
+			for _, t := range page {
+				if seen[t.Id] {
+					continue
+				}
+				seen[t.Id] = true
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if len(page) < limit {
+				return
+			}
+			last := page[len(page)-1].CompletedTime.Truncate(time.Second)
+			if last.IsZero() {
+				return
+			}
+			if !to.IsZero() && !last.Before(to) {
+				// Moving the bound would skip the rest of this second.
+				if limit >= HISTORY_MAX_PAGE_SIZE {
+					yield(tasks.Task{}, ErrHistoryTruncated)
+					return
+				}
+				limit = min(2*limit, HISTORY_MAX_PAGE_SIZE)
+				continue
+			}
+			if !q.From.IsZero() && last.Before(q.From) {
+				return
+			}
+			to, limit = last, q.pageSize()
+		}
+	}
+}
+
+// GetCompletedTasks returns every task closed in the range of q.
+func (c *Client) GetCompletedTasks(ctx context.Context, q HistoryQuery) ([]tasks.Task, error) {
+	var res []tasks.Task
+	for t, err := range c.CompletedTasks(ctx, q) {
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	`context`
+	`errors`
+	`net/http`
+	`strconv`
+	`strings`
+	`testing`
+	`time`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func TestClientCompletedTasks(t *testing.T) {
+	base := time.Date(2024, 12, 9, 10, 0, 0, 0, time.Local)
+	type closed struct {
+		id, projectID, status string
+		completed           time.Time
+	}
+	// The history is kept most recent first, as the server returns it; t3 and
+	// t4 were completed in the same second.
+	history := []closed{
+		{"t6", "p1", "Completed", base.Add(5 * time.Hour)},
+		{"t5", "p2", "Abandoned", base.Add(4 * time.Hour)},
+		{"t4", "p1", "Completed", base.Add(3 * time.Hour)},
+		{"t3", "p2", "Completed", base.Add(3 * time.Hour)},
+		{"t2", "p1", "Completed", base.Add(2 * time.Hour)},
+		{"t1", "p1", "Completed", base.Add(time.Hour)},
+	}
+	requests := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/project/", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			projectID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2/project/"), "/closed")
+			q := r.URL.Query()
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			parse := func(key string) time.Time {
+				tm, _ := time.ParseInLocation(HISTORY_TIME_FORMAT, q.Get(key), time.Local)
+				return tm
+			}
+			from, to := parse("from"), parse("to")
+			page := []map[string]interface{}{}
+			for _, c := range history {
+				switch {
+				case projectID != "all" && c.projectID != projectID, c.status != q.Get("status"):
+				case !from.IsZero() && c.completed.Before(from), !to.IsZero() && c.completed.After(to):
+				case len(page) < limit:
+					status := 2
+					if c.status == "Abandoned" {
+						status = -1
+					}
+					page = append(
+						page, map[string]interface{}{
+							"id": c.id, "projectId": c.projectID, "title": c.id, "status": status,
+							"completedTime": c.completed.UTC().Format(tasks.TIME_FORMAT),
+						},
+					)
+				}
+			}
+			writeJSON(w, http.StatusOK, page)
+		},
+	)
+	c := newTestClient(t, mux)
+
+	testCases := []struct {
+		name     string
+		query    HistoryQuery
+		want     []string
+		requests int
+	}{
+		{"All pages", HistoryQuery{PageSize: 2}, []string{"t6", "t4", "t3", "t2", "t1"}, 4},
+		{"One page", HistoryQuery{}, []string{"t6", "t4", "t3", "t2", "t1"}, 1},
+		{"Range", HistoryQuery{From: base.Add(2 * time.Hour), To: base.Add(3 * time.Hour), PageSize: 2}, []string{"t4", "t3", "t2"}, 2},
+		{"Project", HistoryQuery{ProjectId: "p1", PageSize: 2}, []string{"t6", "t4", "t2", "t1"}, 4},
+		{"Won't do", HistoryQuery{Status: tasks.WontDo}, []string{"t5"}, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				requests = 0
+				got, err := c.GetCompletedTasks(context.Background(), tc.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ids []string
+				for _, task := range got {
+					ids = append(ids, task.Id)
+					if task.CompletedTime.IsZero() {
+						t.Errorf("Expected %s to have a completion time", task.Id)
+					}
+				}
+				if strings.Join(ids, " ") != strings.Join(tc.want, " ") {
+					t.Errorf("Expected %v, got %v", tc.want, ids)
+				}
+				if requests != tc.requests {
+					t.Errorf("Expected %d requests, got %d", tc.requests, requests)
+				}
+			},
+		)
+	}
+
+	requests = 0
+	for task, err := range c.CompletedTasks(context.Background(), HistoryQuery{PageSize: 2}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Id == "t3" {
+			break
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected stopping early to fetch 2 pages, got %d", requests)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetCompletedTasks(ctx, HistoryQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+}
+
+func TestClientCompletedTasksSameSecond(t *testing.T) {
+	completed := time.Date(2024, 12, 9, 10, 0, 0, 0, time.Local)
+	var limits []int
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/project/all/closed", func(w http.ResponseWriter, r *http.Request) {
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			limits = append(limits, limit)
+			// Every task was completed in the same second.
+			page := []map[string]interface{}{}
+			for i := 0; i < min(limit, 3*HISTORY_MAX_PAGE_SIZE); i++ {
+				page = append(
+					page, map[string]interface{}{
+						"id": "t" + strconv.Itoa(i), "status": 2,
+						"completedTime": completed.UTC().Format(tasks.TIME_FORMAT),
+					},
+				)
+			}
+			writeJSON(w, http.StatusOK, page)
+		},
+	)
+	c := newTestClient(t, mux)
+
+	got, err := c.GetCompletedTasks(context.Background(), HistoryQuery{PageSize: HISTORY_MAX_PAGE_SIZE / 2})
+	if !errors.Is(err, ErrHistoryTruncated) || got != nil {
+		t.Fatalf("Expected ErrHistoryTruncated, got %d tasks and %v", len(got), err)
+	}
+	want := []int{HISTORY_MAX_PAGE_SIZE / 2, HISTORY_MAX_PAGE_SIZE / 2, HISTORY_MAX_PAGE_SIZE}
+	if len(limits) != len(want) || limits[0] != want[0] || limits[1] != want[1] || limits[2] != want[2] {
+		t.Errorf("Expected page sizes %v, got %v", want, limits)
+	}
+}
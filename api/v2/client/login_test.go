@@ -0,0 +1,157 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`net/http`
+	`net/http/cookiejar`
+	`net/http/httptest`
+	`net/url`
+	`testing`
+)
+
+// rewriteTransport sends every request to the test server instead of the API.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newTestClient(t *testing.T, handler http.Handler, opts ...ClientOption) *Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	jar, _ := cookiejar.New(nil)
+	hc := &http.Client{Transport: rewriteTransport{target}, Jar: jar}
+	opts = append([]ClientOption{WithHTTPClient(hc)}, opts...)
+	return NewClient("user@example.com", "secret", opts...)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestClientLogin(t *testing.T) {
+	var checkCookie string
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			var lp loginParams
+			json.NewDecoder(r.Body).Decode(&lp)
+			if r.URL.Query().Get("wc") != "true" || r.URL.Query().Get("remember") != "true" {
+				t.Errorf("Expected wc and remember query params, got %s", r.URL.RawQuery)
+			}
+			if lp.Password != "secret" {
+				writeJSON(
+					w, http.StatusInternalServerError,
+					map[string]string{"errorCode": "username_password_not_match", "errorMessage": "wrong password"},
+				)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok123", "userId": "u1", "inboxId": "inbox1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/check/0", func(w http.ResponseWriter, r *http.Request) {
+			if c, err := r.Cookie("t"); err == nil {
+				checkCookie = c.Value
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"checkPoint": 1, "inboxId": "inbox1"})
+		},
+	)
+
+	c := newTestClient(t, mux)
+	if err := c.Login(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if c.AccessToken != "tok123" || c.InboxId != "inbox1" || c.userId != "u1" {
+		t.Fatalf("Expected the session to be stored, got %q %q %q", c.AccessToken, c.InboxId, c.userId)
+	}
+	if _, err := c.Sync(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if checkCookie != "tok123" {
+		t.Errorf("Expected the token to be sent on later requests, got %q", checkCookie)
+	}
+	if len(c.header.Values("Content-Type")) != 0 {
+		t.Errorf("Expected the shared headers to be left unchanged, got %v", c.header)
+	}
+
+	bad := newTestClient(t, mux)
+	bad.password = "wrong"
+	err := bad.Login(context.Background())
+	var apiErr *APIError
+	if !errors.Is(err, ErrInvalidCredentials) || !errors.As(err, &apiErr) || apiErr.Message != "wrong password" {
+		t.Fatalf("Expected invalid credentials, got %v", err)
+	}
+}
+
+func TestClientLoginTwoFactor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"authId": "auth42"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/user/sign/mfa/code/verify", func(w http.ResponseWriter, r *http.Request) {
+			var vp verifyParams
+			json.NewDecoder(r.Body).Decode(&vp)
+			if r.Header.Get("x-verify-id") != "auth42" || vp.Code != "123456" {
+				writeJSON(
+					w, http.StatusInternalServerError,
+					map[string]string{"errorCode": "invalid_verify_code", "errorMessage": "wrong code"},
+				)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok2fa", "inboxId": "inbox1"})
+		},
+	)
+
+	if err := newTestClient(t, mux).Login(context.Background()); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("Expected ErrTwoFactorRequired without a handler, got %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"Valid code", "123456", nil},
+		{"Wrong code", "000000", ErrInvalidCredentials},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			tc.name, func(t *testing.T) {
+				c := newTestClient(
+					t, mux, WithTwoFactor(
+						func(ctx context.Context) (string, error) {
+							return tc.code, nil
+						},
+					),
+				)
+				err := c.Login(context.Background())
+				if tc.wantErr != nil {
+					if !errors.Is(err, tc.wantErr) {
+						t.Fatalf("Expected %v, got %v", tc.wantErr, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.AccessToken != "tok2fa" {
+					t.Errorf("Expected token tok2fa, got %q", c.AccessToken)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	`context`
+	`errors`
+	`fmt`
+	`strings`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/project`
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func (c *Client) GetAllProjects(includeTasks bool) ([]*project.Project, error) {
+	bc, err := c.accountState(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var byProject map[string][]tasks.Task
+	if includeTasks {
+		byProject = tasksByProject(bc.SyncTaskBean.Update)
+	}
+	projs := make([]*project.Project, len(bc.ProjectProfiles))
+	for i, p := range bc.ProjectProfiles {
+		projs[i] = withTasks(p, byProject[p.Id])
+	}
+	return projs, nil
+}
+
+// GetProjectById returns the project with the given id, which may also be
+// "inbox" or the inbox id.
+func (c *Client) GetProjectById(id string, includeTasks bool) (*project.Project, error) {
+	bc, err := c.accountState(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var byProject map[string][]tasks.Task
+	if includeTasks {
+		byProject = tasksByProject(bc.SyncTaskBean.Update)
+	}
+	if id == "inbox" || id == bc.InboxId {
+		return &project.Project{Id: bc.InboxId, Name: "Inbox", Tasks: byProject[bc.InboxId]}, nil
+	}
+	for _, p := range bc.ProjectProfiles {
+		if p.Id == id {
+			return withTasks(p, byProject[p.Id]), nil
+		}
+	}
+	return nil, fmt.Errorf("project %s not found", id)
+}
+
+func (c *Client) GetInbox() (*project.Project, error) {
+	bc, err := c.accountState(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &project.Project{
+		Id:    bc.InboxId,
+		Name:  "Inbox",
+		Tasks: tasksByProject(bc.SyncTaskBean.Update)[bc.InboxId],
+	}, nil
+}
+
+func tasksByProject(ts []tasks.Task) map[string][]tasks.Task {
+	byProject := map[string][]tasks.Task{}
+	for _, t := range ts {
+		byProject[t.ProjectId] = append(byProject[t.ProjectId], t.Clone())
+	}
+	return byProject
+}
+
+// withTasks returns a copy of p holding ts, leaving the shared account state
+// untouched.
+func withTasks(p *project.Project, ts []tasks.Task) *project.Project {
+	pc := *p
+	pc.Tasks = ts
+	return &pc
+}
+
+type batchProjectParams struct {
+	Add    []*project.Project `json:"add"`
+	Update []*project.Project `json:"update"`
+	Delete []string           `json:"delete"`
+}
+
+func (c *Client) batchProjects(params batchProjectParams) (*BatchResponse, error) {
+	if params.Add == nil {
+		params.Add = []*project.Project{}
+	}
+	if params.Update == nil {
+		params.Update = []*project.Project{}
+	}
+	if params.Delete == nil {
+		params.Delete = []string{}
+	}
+	return c.postBatch("batch/project", params)
+}
+
+func (c *Client) CreateNewProject(proj *project.Project) error {
+	if proj.Name == "" {
+		return errors.New("Must provide a name for the project")
+	}
+	if proj.Id == "" {
+		proj.Id = newObjectId()
+	}
+	br, err := c.batchProjects(batchProjectParams{Add: []*project.Project{proj}})
+	if err != nil {
+		return err
+	}
+	return br.errorFor(proj.Id)
+}
+
+func (c *Client) UpdateProject(proj *project.Project) error {
+	if strings.HasPrefix(proj.Id, "inbox") {
+		return errors.New("cannot update inbox project")
+	}
+	br, err := c.batchProjects(batchProjectParams{Update: []*project.Project{proj}})
+	if err != nil {
+		return err
+	}
+	return br.errorFor(proj.Id)
+}
+
+func (c *Client) DeleteProjectById(id string) error {
+	if strings.HasPrefix(id, "inbox") {
+		return errors.New("cannot delete inbox project")
+	}
+	br, err := c.batchProjects(batchProjectParams{Delete: []string{id}})
+	if err != nil {
+		return err
+	}
+	return br.errorFor(id)
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`net/http`
+	`net/url`
+	`strings`
+	`time`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+// Comment is a comment on a task.
+type Comment struct {
+	Id           string
+	TaskId       string
+	ProjectId    string
+	Title        string
+	UserId       string
+	CreatedTime  time.Time
+	ModifiedTime time.Time
+}
+
+type commentJSON struct {
+	Id           string `json:"id"`
+	TaskId       string `json:"taskId"`
+	ProjectId    string `json:"projectId"`
+	Title        string `json:"title"`
+	UserId       string `json:"userId"`
+	CreatedTime  string `json:"createdTime,omitempty"`
+	ModifiedTime string `json:"modifiedTime,omitempty"`
+}
+
+func parseTime(s string) time.Time {
+	tm, err := time.Parse(tasks.TIME_FORMAT, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return tm.Local()
+}
+
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	var cj commentJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+	*c = Comment{
+		Id:           cj.Id,
+		TaskId:       cj.TaskId,
+		ProjectId:    cj.ProjectId,
+		Title:        cj.Title,
+		UserId:       cj.UserId,
+		CreatedTime:  parseTime(cj.CreatedTime),
+		ModifiedTime: parseTime(cj.ModifiedTime),
+	}
+	return nil
+}
+
+// SearchResult holds the tasks, open or closed, and the comments matching a
+// search.
+type SearchResult struct {
+	Tasks    []tasks.Task `json:"tasks"`
+	Comments []Comment    `json:"comments"`
+}
+
+// Search finds tasks and comments containing the keywords in query on the
+// server, without syncing the account.
+func (c *Client) Search(ctx context.Context, query string) (*SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("search query cannot be empty")
+	}
+	u := BASE_URL + "search/all?keywords=" + url.QueryEscape(query)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var sr SearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return nil, err
+	}
+	return &sr, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	`context`
+	`net/http`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func TestClientSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/search/all", func(w http.ResponseWriter, r *http.Request) {
+			if kw := r.URL.Query().Get("keywords"); kw != "quarterly report" {
+				t.Errorf("Expected the keywords to be sent, got %q", kw)
+			}
+			writeJSON(
+				w, http.StatusOK, map[string]interface{}{
+					"tasks": []map[string]interface{}{
+						{"id": "t1", "projectId": "p1", "title": "Write quarterly report"},
+						{
+							"id": "t2", "projectId": "p1", "title": "Send quarterly report", "status": 2,
+							"completedTime": "2024-12-01T10:00:00.000+0000",
+						},
+					},
+					"comments": []map[string]interface{}{
+						{
+							"id": "c1", "taskId": "t3", "projectId": "p2", "title": "See the quarterly report",
+							"createdTime": "2024-12-02T09:30:00.000+0000",
+						},
+					},
+				},
+			)
+		},
+	)
+	c := newTestClient(t, mux)
+
+	sr, err := c.Search(context.Background(), " quarterly report ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sr.Tasks) != 2 || sr.Tasks[1].Status != tasks.Completed || sr.Tasks[1].CompletedTime.IsZero() {
+		t.Errorf("Expected an open and a completed task, got %+v", sr.Tasks)
+	}
+	if len(sr.Comments) != 1 || sr.Comments[0].TaskId != "t3" || sr.Comments[0].CreatedTime.UTC().Hour() != 9 {
+		t.Errorf("Expected the comment on t3, got %+v", sr.Comments)
+	}
+	if _, err := c.Search(context.Background(), "  "); err == nil {
+		t.Error("Expected an empty query to fail")
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`log`
+	`net/http`
+	`net/url`
+
+	`github.com/herzs11/go-ticktick/api/tokenstore`
+)
+
+const SESSION_KEY_PREFIX = "v2-session:"
+
+// Session is what is kept of a signed in web session between runs.
+type Session struct {
+	Token   string         `json:"token"`
+	UserId  string         `json:"userId"`
+	InboxId string         `json:"inboxId"`
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+}
+
+// WithSessionStore makes the client save its session to store after signing
+// in and reuse a saved one while the server still accepts it, so that a
+// password login is only needed when the session expires.
+func WithSessionStore(store tokenstore.Store) ClientOption {
+	return func(c *Client) {
+		c.sessionStore = store
+	}
+}
+
+func (c *Client) sessionKey() string {
+	return SESSION_KEY_PREFIX + c.username
+}
+
+func apiURL() *url.URL {
+	u, _ := url.Parse(BASE_URL)
+	return u
+}
+
+// Session returns the current session, with an empty token if the client has
+// not signed in.
+func (c *Client) Session() Session {
+	s := Session{Token: c.AccessToken, UserId: c.userId, InboxId: c.InboxId}
+	if c.httpClient.Jar != nil {
+		for _, ck := range c.httpClient.Jar.Cookies(apiURL()) {
+			if ck.Name != "t" {
+				s.Cookies = append(s.Cookies, ck)
+			}
+		}
+	}
+	return s
+}
+
+func (c *Client) applySession(s *Session) {
+	c.AccessToken = s.Token
+	c.userId = s.UserId
+	c.InboxId = s.InboxId
+	if c.httpClient.Jar != nil && len(s.Cookies) > 0 {
+		c.httpClient.Jar.SetCookies(apiURL(), s.Cookies)
+	}
+}
+
+// EnsureSession makes sure the client is signed in, resuming the saved
+// session if it is still valid and logging in with the password otherwise.
+// Requests call it as needed, but calling it on startup surfaces login
+// failures early.
+func (c *Client) EnsureSession(ctx context.Context) error {
+	if c.loggedIn {
+		return nil
+	}
+	resumed, err := c.resumeSession(ctx)
+	if err != nil || resumed {
+		return err
+	}
+	return c.Login(ctx)
+}
+
+// resumeSession applies the saved session if the server still accepts it.
+// The saved session is only forgotten when the server rejects it; other
+// errors, such as network failures, are returned and leave it stored.
+func (c *Client) resumeSession(ctx context.Context) (bool, error) {
+	if c.sessionStore == nil {
+		return false, nil
+	}
+	var s Session
+	if err := tokenstore.LoadJSON(c.sessionStore, c.sessionKey(), &s); err != nil || s.Token == "" {
+		return false, nil
+	}
+	c.applySession(&s)
+	if err := c.validateSession(ctx); err != nil {
+		c.applySession(&Session{})
+		var se *StatusError
+		if errors.As(err, &se) && (se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden) {
+			c.forgetSession()
+			return false, nil
+		}
+		return false, err
+	}
+	c.loggedIn = true
+	return true, nil
+}
+
+// validateSession checks that the server still accepts the session token.
+func (c *Client) validateSession(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", BASE_URL+"user/status", nil)
+	if err != nil {
+		return err
+	}
+	req.Header = c.header.Clone()
+	req.AddCookie(&http.Cookie{Name: "t", Value: c.AccessToken})
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Path: req.URL.Path}
+	}
+	return nil
+}
+
+// saveSession stores the session, leaving out the cookies if they would make
+// it too large for the keyring; the token alone is enough to resume it.
+func (c *Client) saveSession() {
+	if c.sessionStore == nil {
+		return
+	}
+	s := c.Session()
+	if data, err := json.Marshal(s); err == nil && len(data) > tokenstore.MAX_KEYRING_SIZE {
+		s.Cookies = nil
+	}
+	if err := tokenstore.SaveJSON(c.sessionStore, c.sessionKey(), s); err != nil {
+		log.Printf("Could not save session: %s\n", err)
+	}
+}
+
+func (c *Client) forgetSession() {
+	if c.sessionStore == nil {
+		return
+	}
+	if err := c.sessionStore.Delete(c.sessionKey()); err != nil {
+		log.Printf("Could not delete session: %s\n", err)
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	`context`
+	`net/http`
+	`path/filepath`
+	`strings`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/tokenstore`
+)
+
+func TestClientSessionStore(t *testing.T) {
+	signons := 0
+	valid := "tok1"
+	statusCode := http.StatusOK
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			signons++
+			http.SetCookie(w, &http.Cookie{Name: "AWSALB", Value: "lb1", Path: "/"})
+			writeJSON(w, http.StatusOK, map[string]string{"token": valid, "userId": "u1", "inboxId": "inbox1"})
+		},
+	)
+	authorized := func(r *http.Request) bool {
+		ck, err := r.Cookie("t")
+		return err == nil && ck.Value == valid
+	}
+	mux.HandleFunc(
+		"/api/v2/user/status", func(w http.ResponseWriter, r *http.Request) {
+			if statusCode != http.StatusOK {
+				w.WriteHeader(statusCode)
+				return
+			}
+			if !authorized(r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"userId": "u1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/check/0", func(w http.ResponseWriter, r *http.Request) {
+			if !authorized(r) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{"checkPoint": 1, "inboxId": "inbox1"})
+		},
+	)
+	store := tokenstore.File{Path: filepath.Join(t.TempDir(), "sessions.json")}
+	sync := func() *Client {
+		c := newTestClient(t, mux, WithSessionStore(store))
+		if _, err := c.Sync(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	sync()
+	var saved Session
+	if err := tokenstore.LoadJSON(store, SESSION_KEY_PREFIX+"user@example.com", &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Token != "tok1" || saved.InboxId != "inbox1" || len(saved.Cookies) != 1 {
+		t.Fatalf("Expected the session to be saved, got %+v", saved)
+	}
+
+	c := sync()
+	if signons != 1 || c.InboxId != "inbox1" {
+		t.Fatalf("Expected the saved session to be reused, got %d sign ons", signons)
+	}
+
+	statusCode = http.StatusServiceUnavailable
+	if _, err := newTestClient(t, mux, WithSessionStore(store)).Sync(context.Background(), 0); err == nil {
+		t.Fatal("Expected a failed session check to be returned")
+	}
+	if err := tokenstore.LoadJSON(store, SESSION_KEY_PREFIX+"user@example.com", &saved); err != nil || signons != 1 {
+		t.Fatalf("Expected a failed session check to keep the saved session, got %v and %d sign ons", err, signons)
+	}
+	statusCode = http.StatusOK
+
+	valid = "tok2"
+	c = sync()
+	if signons != 2 || c.AccessToken != "tok2" {
+		t.Fatalf("Expected an expired session to sign in again, got %d sign ons", signons)
+	}
+	if err := tokenstore.LoadJSON(store, SESSION_KEY_PREFIX+"user@example.com", &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Token != "tok2" {
+		t.Errorf("Expected the new session to be saved, got %q", saved.Token)
+	}
+
+	large := newTestClient(t, mux, WithSessionStore(store))
+	large.AccessToken = "tok2"
+	large.httpClient.Jar.SetCookies(
+		apiURL(), []*http.Cookie{{Name: "big", Value: strings.Repeat("x", tokenstore.MAX_KEYRING_SIZE)}},
+	)
+	large.saveSession()
+	var trimmed Session
+	if err := tokenstore.LoadJSON(store, SESSION_KEY_PREFIX+"user@example.com", &trimmed); err != nil {
+		t.Fatal(err)
+	}
+	if trimmed.Token != "tok2" || len(trimmed.Cookies) != 0 {
+		t.Errorf("Expected a large session to be saved without cookies, got %d cookies", len(trimmed.Cookies))
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`fmt`
+	`net/http`
+	`time`
+)
+
+var ErrCheckpointRejected = errors.New("sync checkpoint rejected by server")
+
+// checkpointRejectedCodes are the statuses batch/check answers an unknown or
+// expired checkpoint with. Others, such as rate limiting, are returned as is.
+var checkpointRejectedCodes = map[int]bool{
+	http.StatusBadRequest: true,
+	http.StatusNotFound:   true,
+	http.StatusGone:       true,
+}
+
+// Sync fetches the changes made since checkPoint from batch/check. A
+// checkPoint of 0 returns the full account state. If the server refuses a
+// non-zero checkPoint, ErrCheckpointRejected is returned and the caller should
+// sync again from 0.
+func (c *Client) Sync(ctx context.Context, checkPoint int64) (*SyncResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%sbatch/check/%d", BASE_URL, checkPoint), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		var se *StatusError
+		if checkPoint != 0 && errors.As(err, &se) && checkpointRejectedCodes[se.StatusCode] {
+			return nil, fmt.Errorf("%w: %s", ErrCheckpointRejected, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var sr SyncResponse
+	err = json.NewDecoder(resp.Body).Decode(&sr)
+	if err != nil {
+		return nil, err
+	}
+	if sr.CheckPoint == 0 && checkPoint != 0 {
+		return nil, ErrCheckpointRejected
+	}
+	if sr.InboxId != "" {
+		c.InboxId = sr.InboxId
+	}
+	return &sr, nil
+}
+
+// accountState returns the full account state, downloading it only if it was
+// not fetched in the last FULL_SYNC_TTL or has been changed since. The
+// response is shared and must not be modified.
+func (c *Client) accountState(ctx context.Context) (*SyncResponse, error) {
+	c.fullSyncMu.Lock()
+	defer c.fullSyncMu.Unlock()
+	if c.fullSync != nil && time.Since(c.fullSyncAt) < FULL_SYNC_TTL {
+		return c.fullSync, nil
+	}
+	sr, err := c.Sync(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	c.fullSync, c.fullSyncAt = sr, time.Now()
+	return sr, nil
+}
+
+// forgetFullSync drops the cached account state, e.g. after a change.
+func (c *Client) forgetFullSync() {
+	c.fullSyncMu.Lock()
+	c.fullSync = nil
+	c.fullSyncMu.Unlock()
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`errors`
+	`net/http`
+	`os`
+	`path/filepath`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tag`
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSyncResponseFixtures(t *testing.T) {
+	testCases := []struct {
+		fixture  string
+		check    func(t *testing.T, sr *SyncResponse)
+		projects int
+		groups   int
+		tags     int
+		filters  int
+		updated  int
+		added    int
+		deleted  int
+	}{
+		{
+			fixture: "batch_check_full.json",
+			tags:    6,
+			updated: 8,
+			check: func(t *testing.T, sr *SyncResponse) {
+				if sr.CheckPoint != 1734223054959 || sr.InboxId != "inbox123471446" {
+					t.Errorf("Unexpected checkpoint %d or inbox %s", sr.CheckPoint, sr.InboxId)
+				}
+				if tg := sr.Tags[0]; tg.Name != "bill" || tg.Label != "Bill" || tg.Color != "#FFD966" || tg.Etag != "mwp2kn3a" {
+					t.Errorf("Unexpected tag %+v", tg)
+				}
+				task := sr.SyncTaskBean.Update[0]
+				if task.Etag != "wjuq5lv2" || task.RepeatFlag != "RRULE:FREQ=YEARLY" || len(task.Tags) != 1 {
+					t.Errorf("Unexpected task %+v", task)
+				}
+			},
+		},
+		{
+			fixture:  "batch_check_incremental.json",
+			projects: 1,
+			groups:   1,
+			filters:  1,
+			updated:  1,
+			added:    1,
+			deleted:  1,
+			check: func(t *testing.T, sr *SyncResponse) {
+				if sr.Tags != nil {
+					t.Errorf("Expected unchanged tags to be nil, got %+v", sr.Tags)
+				}
+				if p := sr.ProjectProfiles[0]; p.GroupId != sr.ProjectGroups[0].Id || p.Name != "Home" {
+					t.Errorf("Unexpected project %+v", p)
+				}
+				if g := sr.ProjectGroups[0]; g.Name != "Personal" || g.SortType != "project" {
+					t.Errorf("Unexpected project group %+v", g)
+				}
+				if f := sr.Filters[0]; f.Name != "Urgent" || len(f.Rule.And) != 1 || f.Rule.And[0].Or[0] != "5" {
+					t.Errorf("Unexpected filter %+v", f)
+				}
+				added := sr.SyncTaskBean.Add[0]
+				if added.Kind != tasks.Checklist || len(added.ChecklistItems) != 2 ||
+					added.ChecklistItems[1].Status != tasks.Completed {
+					t.Errorf("Unexpected added task %+v", added)
+				}
+				if len(sr.SyncTaskBean.TagUpdate) != 1 || sr.SyncTaskBean.TagUpdate[0].Tags[0] != "bills" {
+					t.Errorf("Unexpected tag updates %+v", sr.SyncTaskBean.TagUpdate)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(
+			tc.fixture, func(t *testing.T) {
+				var sr SyncResponse
+				if err := json.Unmarshal(loadFixture(t, tc.fixture), &sr); err != nil {
+					t.Fatal(err)
+				}
+				counts := []struct {
+					name      string
+					got, want int
+				}{
+					{"projects", len(sr.ProjectProfiles), tc.projects},
+					{"project groups", len(sr.ProjectGroups), tc.groups},
+					{"tags", len(sr.Tags), tc.tags},
+					{"filters", len(sr.Filters), tc.filters},
+					{"updated tasks", len(sr.SyncTaskBean.Update), tc.updated},
+					{"added tasks", len(sr.SyncTaskBean.Add), tc.added},
+					{"deleted tasks", len(sr.SyncTaskBean.Delete), tc.deleted},
+				}
+				for _, c := range counts {
+					if c.got != c.want {
+						t.Fatalf("Expected %d %s, got %d", c.want, c.name, c.got)
+					}
+				}
+				tc.check(t, &sr)
+			},
+		)
+	}
+}
+
+func TestSyncResponseUndecodableFilters(t *testing.T) {
+	data := []byte(`{
+		"checkPoint": 5,
+		"filters": [
+			{"id": "f1", "name": "Offset", "rule": "{\"and\":[{\"or\":[{\"offset\":1}],\"conditionName\":\"dueDate\"}],\"type\":1}"},
+			{"id": "f2", "name": "Broken", "rule": "not json"},
+			{"id": "f3", "name": "Tagged", "rule": "{\"and\":[{\"or\":[\"home\"],\"conditionName\":\"tag\"}],\"type\":0}"}
+		]
+	}`)
+	var sr SyncResponse
+	if err := json.Unmarshal(data, &sr); err != nil {
+		t.Fatalf("Expected undecodable filter rules not to fail the sync, got %v", err)
+	}
+	if len(sr.Filters) != 3 || sr.CheckPoint != 5 {
+		t.Fatalf("Unexpected sync response %+v", sr)
+	}
+	if sr.Filters[0].RawRule == "" || sr.Filters[1].RawRule != "not json" || sr.Filters[2].RawRule != "" {
+		t.Errorf("Expected only the undecodable rules kept raw, got %+v", sr.Filters)
+	}
+}
+
+func TestClientSync(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/check/1734223054959", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(loadFixture(t, "batch_check_incremental.json"))
+		},
+	)
+	c := newTestClient(t, mux)
+	sr, err := c.Sync(context.Background(), 1734223054959)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.CheckPoint != 1734309454012 || len(sr.SyncTaskBean.Add) != 1 || c.InboxId != "inbox123471446" {
+		t.Fatalf("Unexpected sync response %+v", sr)
+	}
+}
+
+func TestClientSyncRejectedCheckpoint(t *testing.T) {
+	testCases := []struct {
+		status   int
+		rejected bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusGone, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusForbidden, false},
+		{http.StatusInternalServerError, false},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			http.StatusText(tc.status), func(t *testing.T) {
+				mux := http.NewServeMux()
+				mux.HandleFunc(
+					"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+						writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+					},
+				)
+				mux.HandleFunc(
+					"/api/v2/batch/check/100", func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(tc.status)
+					},
+				)
+				c := newTestClient(t, mux)
+				_, err := c.Sync(context.Background(), 100)
+				if err == nil || errors.Is(err, ErrCheckpointRejected) != tc.rejected {
+					t.Fatalf("Expected rejected checkpoint to be %t, got %v", tc.rejected, err)
+				}
+			},
+		)
+	}
+}
+
+func TestClientAccountStateFetchedOnce(t *testing.T) {
+	fetches := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/check/0", func(w http.ResponseWriter, r *http.Request) {
+			fetches++
+			w.Write(loadFixture(t, "batch_check_full.json"))
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/tag", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"id2etag": map[string]string{}, "id2error": map[string]string{}})
+		},
+	)
+	c := newTestClient(t, mux)
+
+	if _, err := c.GetAllProjects(true); err != nil {
+		t.Fatal(err)
+	}
+	inbox, err := c.GetInbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox.Tasks[0].Title = "Changed"
+	p, err := c.GetProjectById("inbox", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Tasks[0].Title == "Changed" {
+		t.Error("Expected returned tasks not to share the cached account state")
+	}
+	if _, err := c.GetFilters(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListTags(); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 1 {
+		t.Fatalf("Expected the account state to be fetched once, got %d", fetches)
+	}
+
+	if err := c.UpdateTag(&tag.Tag{Name: "work", Label: "Work"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ListTags(); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Fatalf("Expected a change to refetch the account state, got %d fetches", fetches)
+	}
+}
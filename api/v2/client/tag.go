@@ -0,0 +1,125 @@
+package client
+
+import (
+	`bytes`
+	`context`
+	`encoding/json`
+	`errors`
+	`net/http`
+	`net/url`
+	`strings`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tag`
+)
+
+type batchTagParams struct {
+	Add    []*tag.Tag `json:"add"`
+	Update []*tag.Tag `json:"update"`
+}
+
+type renameTagParams struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName"`
+}
+
+// ListTags returns the tags of the account.
+func (c *Client) ListTags() ([]*tag.Tag, error) {
+	sr, err := c.accountState(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]*tag.Tag, len(sr.Tags))
+	for i, t := range sr.Tags {
+		tc := *t
+		tags[i] = &tc
+	}
+	return tags, nil
+}
+
+func (c *Client) batchTags(params batchTagParams) error {
+	if params.Add == nil {
+		params.Add = []*tag.Tag{}
+	}
+	if params.Update == nil {
+		params.Update = []*tag.Tag{}
+	}
+	br, err := c.postBatch("batch/tag", params)
+	if err != nil {
+		return err
+	}
+	for _, t := range append(params.Add, params.Update...) {
+		if err := br.errorFor(t.Name); err != nil {
+			return err
+		}
+		if etag, ok := br.Id2Etag[t.Name]; ok {
+			t.Etag = etag
+		}
+	}
+	return nil
+}
+
+// CreateTag creates t, deriving its name from the label if it has none.
+func (c *Client) CreateTag(t *tag.Tag) error {
+	if t.Label == "" && t.Name == "" {
+		return errors.New("Must provide a label for the tag")
+	}
+	if t.Label == "" {
+		t.Label = t.Name
+	}
+	if t.Name == "" {
+		t.Name = tag.NameOf(t.Label)
+	}
+	return c.batchTags(batchTagParams{Add: []*tag.Tag{t}})
+}
+
+// UpdateTag saves the color, parent and sort order of an existing tag. Use
+// RenameTag to change its name.
+func (c *Client) UpdateTag(t *tag.Tag) error {
+	if t.Name == "" {
+		return errors.New("tag with an empty name cannot be updated")
+	}
+	return c.batchTags(batchTagParams{Update: []*tag.Tag{t}})
+}
+
+func (c *Client) putTag(path string, params renameTagParams) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", BASE_URL+path, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// RenameTag renames the tag named oldName to newLabel. The server replaces
+// the tag on every task that has it.
+func (c *Client) RenameTag(oldName, newLabel string) error {
+	if strings.TrimSpace(newLabel) == "" {
+		return errors.New("Must provide a new name for the tag")
+	}
+	return c.putTag("tag/rename", renameTagParams{Name: tag.NameOf(oldName), NewName: newLabel})
+}
+
+// MergeTags moves every task tagged from to the tag into and deletes from.
+func (c *Client) MergeTags(from, into string) error {
+	return c.putTag("tag/merge", renameTagParams{Name: tag.NameOf(from), NewName: tag.NameOf(into)})
+}
+
+// DeleteTag deletes the tag, removing it from every task that has it.
+func (c *Client) DeleteTag(name string) error {
+	req, err := http.NewRequest("DELETE", BASE_URL+"tag?name="+url.QueryEscape(tag.NameOf(name)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
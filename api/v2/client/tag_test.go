@@ -0,0 +1,109 @@
+package client
+
+import (
+	`encoding/json`
+	`io`
+	`net/http`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tag`
+)
+
+func TestClientTags(t *testing.T) {
+	type call struct {
+		method, path, query string
+		body                map[string]interface{}
+	}
+	var calls []call
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		c := call{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &c.body)
+		calls = append(calls, c)
+		if r.URL.Path == "/api/v2/batch/tag" {
+			writeJSON(
+				w, http.StatusOK,
+				map[string]interface{}{"id2etag": map[string]string{"work": "e1"}, "id2error": map[string]string{}},
+			)
+		}
+	}
+	for _, path := range []string{"/api/v2/batch/tag", "/api/v2/tag/rename", "/api/v2/tag/merge", "/api/v2/tag"} {
+		mux.HandleFunc(path, record)
+	}
+	mux.HandleFunc(
+		"/api/v2/batch/check/0", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(loadFixture(t, "batch_check_full.json"))
+		},
+	)
+	c := newTestClient(t, mux)
+
+	tags, err := c.ListTags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 6 || tags[0].Label != "Bill" {
+		t.Fatalf("Unexpected tags %+v", tags)
+	}
+
+	work := &tag.Tag{Label: "Work", Color: "#FF0000"}
+	if err := c.CreateTag(work); err != nil {
+		t.Fatal(err)
+	}
+	if work.Name != "work" || work.Etag != "e1" {
+		t.Errorf("Expected the name derived from the label and the etag set, got %+v", work)
+	}
+	work.Parent = "projects"
+	if err := c.UpdateTag(work); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameTag("Work", "Job"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MergeTags("CX", "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteTag("Job"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RenameTag("job", " "); err == nil {
+		t.Error("Expected renaming to an empty name to fail")
+	}
+
+	want := []struct {
+		method, path, query string
+		check               func(body map[string]interface{}) bool
+	}{
+		{"POST", "/api/v2/batch/tag", "", func(b map[string]interface{}) bool {
+			add := b["add"].([]interface{})
+			return len(add) == 1 && add[0].(map[string]interface{})["label"] == "Work"
+		}},
+		{"POST", "/api/v2/batch/tag", "", func(b map[string]interface{}) bool {
+			update := b["update"].([]interface{})
+			return len(update) == 1 && update[0].(map[string]interface{})["parent"] == "projects"
+		}},
+		{"PUT", "/api/v2/tag/rename", "", func(b map[string]interface{}) bool {
+			return b["name"] == "work" && b["newName"] == "Job"
+		}},
+		{"PUT", "/api/v2/tag/merge", "", func(b map[string]interface{}) bool {
+			return b["name"] == "cx" && b["newName"] == "work"
+		}},
+		{"DELETE", "/api/v2/tag", "name=job", func(b map[string]interface{}) bool {
+			return b == nil
+		}},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %d calls, got %+v", len(want), calls)
+	}
+	for i, w := range want {
+		got := calls[i]
+		if got.method != w.method || got.path != w.path || got.query != w.query || !w.check(got.body) {
+			t.Errorf("Call %d: expected %s %s?%s, got %+v", i, w.method, w.path, w.query, got)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	`bytes`
+	`encoding/json`
+	`errors`
+	`fmt`
+	`net/http`
+	`net/url`
+	`time`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+type TaskMove struct {
+	FromProjectId string `json:"fromProjectId"`
+	TaskId        string `json:"taskId"`
+	ToProjectId   string `json:"toProjectId"`
+}
+
+func (c *Client) MoveTasks(moves []TaskMove) error {
+	if len(moves) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(moves)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", BASE_URL+"batch/taskProject", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (br *BatchResponse) errorFor(id string) error {
+	if msg, ok := br.Id2Error[id]; ok {
+		return fmt.Errorf("Batch request failed for %s: %s", id, msg)
+	}
+	return nil
+}
+
+// GetTask fetches the task with task.Id, passing task.ProjectId along if it
+// is set.
+func (c *Client) GetTask(task *tasks.Task) error {
+	if task.Id == "" {
+		return errors.New("task with an empty id cannot be fetched")
+	}
+	u := BASE_URL + "task/" + url.PathEscape(task.Id)
+	if task.ProjectId != "" {
+		u += "?projectId=" + url.QueryEscape(task.ProjectId)
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var t tasks.Task
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return err
+	}
+	*task = t
+	return nil
+}
+
+func (c *Client) CreateTask(task *tasks.Task) error {
+	if task.Title == "" {
+		return errors.New("Task must have a title")
+	}
+	if task.ProjectId == "" || task.ProjectId == "inbox" {
+		inboxID, err := c.GetInboxId()
+		if err != nil {
+			return err
+		}
+		task.ProjectId = inboxID
+	}
+	br, err := c.BatchTasks([]*tasks.Task{task}, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := br.errorFor(task.Id); err != nil {
+		return err
+	}
+	task.Etag = br.Id2Etag[task.Id]
+	return nil
+}
+
+func (c *Client) UpdateTask(task *tasks.Task) error {
+	if task.Id == "" {
+		return errors.New("task with an empty id cannot be updated")
+	}
+	br, err := c.BatchTasks(nil, []*tasks.Task{task}, nil)
+	if err != nil {
+		return err
+	}
+	if err := br.errorFor(task.Id); err != nil {
+		return err
+	}
+	task.Etag = br.Id2Etag[task.Id]
+	return nil
+}
+
+func (c *Client) CompleteTask(task *tasks.Task) error {
+	completed := *task
+	completed.Status = tasks.Completed
+	completed.CompletedTime = time.Now().Local()
+	if err := c.UpdateTask(&completed); err != nil {
+		return err
+	}
+	task.Status = completed.Status
+	task.CompletedTime = completed.CompletedTime
+	return nil
+}
+
+func (c *Client) DeleteTask(task *tasks.Task) error {
+	br, err := c.BatchTasks(nil, nil, []TaskRef{{TaskId: task.Id, ProjectId: task.ProjectId}})
+	if err != nil {
+		return err
+	}
+	return br.errorFor(task.Id)
+}
+
+func (c *Client) MoveTask(task *tasks.Task, toProjectID string) error {
+	if toProjectID == "" || toProjectID == "inbox" {
+		inboxID, err := c.GetInboxId()
+		if err != nil {
+			return err
+		}
+		toProjectID = inboxID
+	}
+	if task.ProjectId == toProjectID {
+		return nil
+	}
+	err := c.MoveTasks([]TaskMove{{FromProjectId: task.ProjectId, TaskId: task.Id, ToProjectId: toProjectID}})
+	if err != nil {
+		return err
+	}
+	task.ProjectId = toProjectID
+	return nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	`net/http`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func TestClientGetTask(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/task/t1", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("projectId") != "p1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeJSON(
+				w, http.StatusOK,
+				map[string]interface{}{"id": "t1", "projectId": "p1", "title": "Write report", "etag": "a1"},
+			)
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/batch/check/0", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(loadFixture(t, "batch_check_incremental.json"))
+		},
+	)
+	c := newTestClient(t, mux)
+
+	task := tasks.Task{Id: "t1", ProjectId: "p1"}
+	if err := c.GetTask(&task); err != nil {
+		t.Fatal(err)
+	}
+	if task.Title != "Write report" || task.Etag != "a1" {
+		t.Errorf("Expected the fetched task, got %+v", task)
+	}
+	if err := c.GetTask(&tasks.Task{Id: "t1", ProjectId: "p2"}); err == nil {
+		t.Error("Expected an error for a task not in the project")
+	}
+
+	testCases := []struct {
+		id   string
+		name string
+	}{
+		{"6604bd155250b04bff18821e", "Home"},
+		{"inbox", "Inbox"},
+		{"inbox123471446", "Inbox"},
+	}
+	for _, tc := range testCases {
+		p, err := c.GetProjectById(tc.id, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Name != tc.name {
+			t.Errorf("Expected project %s for %s, got %s", tc.name, tc.id, p.Name)
+		}
+	}
+	if _, err := c.GetProjectById("missing", false); err == nil {
+		t.Error("Expected an error for an unknown project")
+	}
+}
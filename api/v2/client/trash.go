@@ -0,0 +1,137 @@
+package client
+
+import (
+	`bytes`
+	`context`
+	`encoding/json`
+	`io`
+	`iter`
+	`net/http`
+	`net/url`
+	`strconv`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+const TRASH_PAGE_SIZE = 50
+
+type trashPage struct {
+	Tasks []tasks.Task `json:"tasks"`
+	Next  int          `json:"next"`
+}
+
+func (c *Client) trashPage(ctx context.Context, start, limit int) (*trashPage, error) {
+	v := url.Values{}
+	v.Set("start", strconv.Itoa(start))
+	v.Set("limit", strconv.Itoa(limit))
+	req, err := http.NewRequestWithContext(ctx, "GET", BASE_URL+"project/all/trash/pagination?"+v.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var page trashPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// TrashedTasks streams the tasks in the trash, most recently deleted first,
+// fetching pages of pageSize tasks, TRASH_PAGE_SIZE if zero, as the iteration
+// goes on. Iteration stops after the first error.
+func (c *Client) TrashedTasks(ctx context.Context, pageSize int) iter.Seq2[tasks.Task, error] {
+	if pageSize <= 0 {
+		pageSize = TRASH_PAGE_SIZE
+	}
+	return func(yield func(tasks.Task, error) bool) {
+		start := 0
+		for {
+			page, err := c.trashPage(ctx, start, pageSize)
+			if err != nil {
+				yield(tasks.Task{}, err)
+				return
+			}
+			for _, t := range page.Tasks {
+				if !yield(t, nil) {
+					return
+				}
+			}
+			if len(page.Tasks) < pageSize {
+				return
+			}
+			if page.Next > start {
+				start = page.Next
+			} else {
+				start += len(page.Tasks)
+			}
+		}
+	}
+}
+
+// GetTrash returns every task in the trash.
+func (c *Client) GetTrash(ctx context.Context) ([]tasks.Task, error) {
+	var res []tasks.Task
+	for t, err := range c.TrashedTasks(ctx, 0) {
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+func (c *Client) sendTrash(ctx context.Context, method, path string, refs []TaskRef) error {
+	var body io.Reader
+	if refs != nil {
+		data, err := json.Marshal(refs)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, BASE_URL+path, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// RestoreTasks moves tasks out of the trash and back into the projects they
+// were deleted from.
+func (c *Client) RestoreTasks(ctx context.Context, refs []TaskRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	return c.sendTrash(ctx, "POST", "trash/restore", refs)
+}
+
+func (c *Client) RestoreTask(ctx context.Context, task *tasks.Task) error {
+	return c.RestoreTasks(ctx, []TaskRef{{TaskId: task.Id, ProjectId: task.ProjectId}})
+}
+
+// DeleteTasksForever removes tasks from the trash. They cannot be restored
+// afterwards.
+func (c *Client) DeleteTasksForever(ctx context.Context, refs []TaskRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+	return c.sendTrash(ctx, "POST", "trash/delete", refs)
+}
+
+func (c *Client) DeleteTaskForever(ctx context.Context, task *tasks.Task) error {
+	return c.DeleteTasksForever(ctx, []TaskRef{{TaskId: task.Id, ProjectId: task.ProjectId}})
+}
+
+// EmptyTrash deletes every task in the trash forever.
+func (c *Client) EmptyTrash(ctx context.Context) error {
+	return c.sendTrash(ctx, "DELETE", "trash/empty", nil)
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	`context`
+	`encoding/json`
+	`net/http`
+	`strconv`
+	`testing`
+
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
+func TestClientTrash(t *testing.T) {
+	trash := []map[string]interface{}{}
+	for _, id := range []string{"t1", "t2", "t3", "t4", "t5"} {
+		trash = append(trash, map[string]interface{}{"id": id, "projectId": "p1", "title": id, "deleted": 1})
+	}
+	requests := 0
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc(
+		"/api/v2/user/signon", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]string{"token": "tok1"})
+		},
+	)
+	mux.HandleFunc(
+		"/api/v2/project/all/trash/pagination", func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			end := min(start+limit, len(trash))
+			writeJSON(w, http.StatusOK, map[string]interface{}{"tasks": trash[start:end], "next": end})
+		},
+	)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		call := r.Method + " " + r.URL.Path
+		var refs []TaskRef
+		if json.NewDecoder(r.Body).Decode(&refs) == nil {
+			for _, ref := range refs {
+				call += " " + ref.ProjectId + "/" + ref.TaskId
+			}
+		}
+		calls = append(calls, call)
+	}
+	for _, path := range []string{"/api/v2/trash/restore", "/api/v2/trash/delete", "/api/v2/trash/empty"} {
+		mux.HandleFunc(path, record)
+	}
+	c := newTestClient(t, mux)
+
+	got, err := c.GetTrash(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[4].Id != "t5" || requests != 1 {
+		t.Fatalf("Expected the trash in one request, got %d tasks in %d requests", len(got), requests)
+	}
+
+	requests = 0
+	var ids []string
+	for task, err := range c.TrashedTasks(context.Background(), 2) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, task.Id)
+	}
+	if len(ids) != 5 || ids[2] != "t3" || requests != 3 {
+		t.Errorf("Expected 5 tasks in 3 pages, got %v in %d", ids, requests)
+	}
+
+	ctx := context.Background()
+	if err := c.RestoreTask(ctx, &tasks.Task{Id: "t1", ProjectId: "p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteTasksForever(ctx, []TaskRef{{TaskId: "t2", ProjectId: "p1"}, {TaskId: "t3", ProjectId: "p1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RestoreTasks(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.EmptyTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /api/v2/trash/restore p1/t1",
+		"POST /api/v2/trash/delete p1/t2 p1/t3",
+		"DELETE /api/v2/trash/empty",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], calls[i])
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package client
 
+import (
+	`github.com/herzs11/go-ticktick/api/v1/types/filter`
+	`github.com/herzs11/go-ticktick/api/v1/types/project`
+	`github.com/herzs11/go-ticktick/api/v1/types/tag`
+	`github.com/herzs11/go-ticktick/api/v1/types/tasks`
+)
+
 type loginResponse struct {
 	Token          string `json:"token"`
+	AuthId         string `json:"authId"`
 	UserId         string `json:"userId"`
 	UserCode       string `json:"userCode"`
 	Username       string `json:"Username"`
@@ -19,3 +27,37 @@ type loginResponse struct {
 	Pro            bool   `json:"pro"`
 	Ds             bool   `json:"ds"`
 }
+
+// SyncTaskBean holds the tasks changed since the checkpoint. TagUpdate lists
+// tasks changed only by renaming or merging one of their tags.
+type SyncTaskBean struct {
+	Update    []tasks.Task `json:"update"`
+	TagUpdate []tasks.Task `json:"tagUpdate"`
+	Delete    []TaskRef    `json:"delete"`
+	Add       []tasks.Task `json:"add"`
+	Empty     bool         `json:"empty"`
+}
+
+// ProjectGroup is a folder of projects in the app's sidebar.
+type ProjectGroup struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Etag      string `json:"etag,omitempty"`
+	ShowAll   bool   `json:"showAll"`
+	SortOrder int64  `json:"sortOrder"`
+	SortType  string `json:"sortType,omitempty"`
+	ViewMode  string `json:"viewMode,omitempty"`
+	Deleted   int    `json:"deleted,omitempty"`
+}
+
+// SyncResponse is the payload of batch/check. Lists the server left out as
+// unchanged since the checkpoint are nil.
+type SyncResponse struct {
+	CheckPoint      int64              `json:"checkPoint"`
+	SyncTaskBean    SyncTaskBean       `json:"syncTaskBean"`
+	ProjectProfiles []*project.Project `json:"projectProfiles"`
+	ProjectGroups   []*ProjectGroup    `json:"projectGroups"`
+	Tags            []*tag.Tag         `json:"tags"`
+	Filters         []*filter.Filter   `json:"filters"`
+	InboxId         string             `json:"inboxId"`
+}
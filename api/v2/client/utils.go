@@ -3,6 +3,8 @@ package client
 import (
 	`crypto/rand`
 	`encoding/hex`
+	`fmt`
+	`time`
 )
 
 func randomHex(n int) (string, error) {
@@ -12,3 +14,9 @@ func randomHex(n int) (string, error) {
 	}
 	return hex.EncodeToString(bytes), nil
 }
+
+func newObjectId() string {
+	ts := fmt.Sprintf("%08x", uint32(time.Now().Unix()))
+	r, _ := randomHex(8)
+	return ts + r
+}